@@ -0,0 +1,172 @@
+package n43
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// AnonymizeOptions configures Anonymize. The zero value gives random
+// pseudonyms and keeps the amounts.
+type AnonymizeOptions struct {
+	// Seed makes the pseudonyms reproducible: the same seed gives the same
+	// pseudonym to the same value. By default a random seed is used, so the
+	// pseudonyms cannot be matched against known values.
+	Seed string
+	// Scale multiplies every amount and balance. Zero stands for the default,
+	// 1, which keeps the amounts. Negative values, which would turn debits
+	// into credits, are an error.
+	Scale float64
+}
+
+type anonymizer struct {
+	seed string
+}
+
+// Anonymize returns a copy of the document with its identifying data
+// replaced by pseudonyms: branch codes, account numbers, account names,
+// document numbers, references and extra information. Every letter and digit
+// is replaced by another one, keeping the length and layout of the fields
+// and the masks (X) of card numbers. The same value always gets the same
+// pseudonym, so equal references still match. Bank codes, dates and concept
+// codes are kept.
+//
+// The balances, footers and end of file record count are recomputed, so the
+// result validates even when amounts are scaled.
+func Anonymize(n *Norma43, anonymizeOptions *AnonymizeOptions) (*Norma43, error) {
+	ao := new(AnonymizeOptions)
+
+	ao.Scale = 1
+
+	if anonymizeOptions != nil {
+		ao.Seed = anonymizeOptions.Seed
+		if anonymizeOptions.Scale < 0 {
+			return nil, errors.New("invalid anonymize scale " + strconv.FormatFloat(anonymizeOptions.Scale, 'f', -1, 64))
+		}
+		if anonymizeOptions.Scale != 0 {
+			ao.Scale = anonymizeOptions.Scale
+		}
+	}
+
+	if ao.Seed == "" {
+		seed := make([]byte, 16)
+		rand.Read(seed)
+		ao.Seed = hex.EncodeToString(seed)
+	}
+
+	an := &anonymizer{seed: ao.Seed}
+
+	out := &Norma43{Accounts: []*Account{}}
+	for _, account := range n.Accounts {
+		a := an.account(account, ao.Scale)
+		out.Accounts = append(out.Accounts, a)
+		out.ReportedEntries += a.Records()
+	}
+
+	return out, nil
+}
+
+func (an *anonymizer) account(account *Account, scale float64) *Account {
+	h := new(Header)
+	if account.Header != nil {
+		*h = *account.Header
+	}
+
+	// The same pseudonyms as in the texts, so an account number quoted in a
+	// concept still matches the account.
+	h.BranchCode = an.text(h.BranchCode)
+	h.AccountNumber = an.text(h.AccountNumber)
+	h.AccountName = an.text(h.AccountName)
+	h.InitialBalance = roundAmount(h.InitialBalance * scale)
+
+	a := &Account{Header: h}
+
+	balance := h.InitialBalance
+	for _, movement := range account.Movements {
+		m := *movement
+
+		m.BranchCode = an.text(m.BranchCode)
+		m.DocumentNumber = an.text(m.DocumentNumber)
+		m.Description = an.text(m.Description)
+		m.Amount = roundAmount(m.Amount * scale)
+		m.ExtraInformation = make([]string, 0, len(movement.ExtraInformation))
+		for _, line := range movement.ExtraInformation {
+			m.ExtraInformation = append(m.ExtraInformation, an.text(line))
+		}
+
+		balance += m.Amount
+		m.Balance = roundAmount(balance)
+		m.FilteredSum = roundAmount(balance - h.InitialBalance)
+
+		a.Movements = append(a.Movements, &m)
+	}
+
+	a.Footer = a.ComputeFooter()
+
+	return a
+}
+
+// text replaces every word of s by its pseudonym, keeping the spaces and
+// punctuation around them.
+func (an *anonymizer) text(s string) string {
+	var b strings.Builder
+
+	word := []rune{}
+	flush := func() {
+		if len(word) > 0 {
+			b.WriteString(an.pseudonym(string(word)))
+			word = word[:0]
+		}
+	}
+
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+
+	return b.String()
+}
+
+// pseudonym returns a word of the same length and character classes derived
+// from the seed and the word. Words made only of zeros, like empty document
+// numbers, are kept, as are the X masking the digits of card numbers.
+func (an *anonymizer) pseudonym(word string) string {
+	if strings.Trim(word, "0") == "" {
+		return word
+	}
+	masked := strings.ContainsAny(word, "0123456789")
+
+	sum := sha256.Sum256([]byte(an.seed + "\x00" + word))
+	random := sum[:]
+
+	out := []rune(word)
+	for i, r := range out {
+		if len(random) == 0 {
+			sum = sha256.Sum256(sum[:])
+			random = sum[:]
+		}
+		v := random[0]
+		random = random[1:]
+
+		switch {
+		case masked && r == 'X':
+		case unicode.IsDigit(r):
+			out[i] = rune('0' + v%10)
+		case unicode.IsLower(r):
+			out[i] = rune('a' + v%26)
+		default:
+			out[i] = rune('A' + v%26)
+		}
+	}
+
+	return string(out)
+}
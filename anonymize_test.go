@@ -0,0 +1,64 @@
+package n43
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_anonymize(t *testing.T) {
+	in := parseSample(t)
+	in.Accounts[0].Movements[4].ExtraInformation = append(in.Accounts[0].Movements[4].ExtraInformation, "TRANSFER TO 3333444412")
+	out, err := Anonymize(in, &AnonymizeOptions{Seed: "test", Scale: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, oh := out.Accounts[0].Header, in.Accounts[0].Header
+	if h.BankCode != oh.BankCode || h.BranchCode == oh.BranchCode || h.AccountNumber == oh.AccountNumber || len(h.AccountNumber) != 10 {
+		t.Errorf("Expected a new branch and account number in the same bank, but %s %s %s found", h.BankCode, h.BranchCode, h.AccountNumber)
+	}
+	if len(h.AccountName) != len(oh.AccountName) || h.AccountName == oh.AccountName || !strings.HasSuffix(h.AccountName, " ************") {
+		t.Errorf("Expected a pseudonym of the account name, but %q found", h.AccountName)
+	}
+	if oh.AccountNumber != "3333444412" || oh.AccountName != "ACCOUNT NAME ************" {
+		t.Errorf("Expected the document not to be modified")
+	}
+
+	movements := out.Accounts[0].Movements
+	if movements[0].Amount != -47.98 || h.InitialBalance != 4926.86 {
+		t.Errorf("Expected scaled amounts, but %.2f and %.2f found", movements[0].Amount, h.InitialBalance)
+	}
+
+	card := movements[0].ExtraInformation[0]
+	if !strings.Contains(card, "XXXXXXXX") || strings.Contains(card, "SHOP") || len(card) != len(in.Accounts[0].Movements[0].ExtraInformation[0]) {
+		t.Errorf("Expected the card mask and no original text, but %q found", card)
+	}
+	if movements[1].ExtraInformation[0] != movements[2].ExtraInformation[0] {
+		t.Errorf("Expected the same pseudonym for the same text, but %q and %q found", movements[1].ExtraInformation[0], movements[2].ExtraInformation[0])
+	}
+	if strings.TrimSpace(movements[0].DocumentNumber) != "0000000000" {
+		t.Errorf("Expected the empty document number to be kept, but %q found", movements[0].DocumentNumber)
+	}
+	if strings.TrimSpace(movements[1].Description) == strings.TrimSpace(in.Accounts[0].Movements[1].Description) {
+		t.Errorf("Expected a pseudonym of the references, but %q found", movements[1].Description)
+	}
+
+	if text := movements[4].ExtraInformation[2]; !strings.HasSuffix(text, " "+h.AccountNumber) {
+		t.Errorf("Expected the account number pseudonym in the text, but %q found", text)
+	}
+
+	if errs := out.Validate(); len(errs) != 0 {
+		t.Errorf("Expected a valid document, but %v found", errs)
+	}
+
+	if again, _ := Anonymize(in, &AnonymizeOptions{Seed: "test", Scale: 2}); !reflect.DeepEqual(out, again) {
+		t.Errorf("Expected the same pseudonyms with the same seed")
+	}
+	if other, _ := Anonymize(in, nil); other.Accounts[0].Header.AccountNumber == h.AccountNumber || other.Accounts[0].Header.InitialBalance != oh.InitialBalance {
+		t.Errorf("Expected a random seed and unscaled amounts by default")
+	}
+	if _, err := Anonymize(in, &AnonymizeOptions{Scale: -1}); err == nil {
+		t.Errorf("Expected an error with a negative scale, but nothing found")
+	}
+}
@@ -0,0 +1,623 @@
+package n43
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CAMT053Version string
+
+const (
+	CAMT053_V02 CAMT053Version = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+	CAMT053_V08 CAMT053Version = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+)
+
+// CAMT053Writer writes a Norma43 document as an ISO 20022 camt.053 bank to
+// customer statement with one statement per account.
+type CAMT053Writer struct {
+	w          io.Writer
+	camtOption *CAMT053Options
+}
+
+type CAMT053Options struct {
+	Version CAMT053Version
+	// MessageID is the group header message identification. By default it is
+	// built from the first account and its end date.
+	MessageID string
+	// CreationTime is the creation date of the message, now by default.
+	CreationTime time.Time
+}
+
+// camtTransactionCodes maps the AEB common concept codes to the ISO 20022
+// bank transaction domain, family and sub family codes of credit and debit
+// movements.
+var camtTransactionCodes = map[string][2][3]string{
+	CONCEPT_CHECKS:        {{"PMNT", "RCHQ", "CCHQ"}, {"PMNT", "ICHQ", "CCHQ"}},
+	CONCEPT_DEPOSITS:      {{"PMNT", "CNTR", "CDPT"}, {"PMNT", "CNTR", "CWDL"}},
+	CONCEPT_DIRECT_DEBITS: {{"PMNT", "RDDT", "ESDD"}, {"PMNT", "IDDT", "ESDD"}},
+	CONCEPT_TRANSFERS:     {{"PMNT", "RCDT", "ESCT"}, {"PMNT", "ICDT", "ESCT"}},
+	CONCEPT_LOANS:         {{"LDAS", "CSLN", "DDWN"}, {"LDAS", "CSLN", "PPAY"}},
+	CONCEPT_REMITTANCES:   {{"TRAD", "DOCC", "OTHR"}, {"TRAD", "DOCC", "OTHR"}},
+	CONCEPT_SUBSCRIPTIONS: {{"SECU", "CORP", "OTHR"}, {"SECU", "CORP", "OTHR"}},
+	CONCEPT_DIVIDENDS:     {{"SECU", "CORP", "DVCA"}, {"SECU", "CORP", "DVCA"}},
+	CONCEPT_SECURITIES:    {{"SECU", "SETT", "TRAD"}, {"SECU", "SETT", "TRAD"}},
+	CONCEPT_FUEL_CHECKS:   {{"PMNT", "CCRD", "POSD"}, {"PMNT", "CCRD", "POSD"}},
+	CONCEPT_ATM:           {{"PMNT", "CCRD", "CDPT"}, {"PMNT", "CCRD", "CWDL"}},
+	CONCEPT_CARDS:         {{"PMNT", "CCRD", "POSD"}, {"PMNT", "CCRD", "POSD"}},
+	CONCEPT_FOREIGN:       {{"PMNT", "RCDT", "XBCT"}, {"PMNT", "ICDT", "XBCT"}},
+	CONCEPT_RETURNS:       {{"PMNT", "IDDT", "UPDD"}, {"PMNT", "RDDT", "UPDD"}},
+	CONCEPT_PAYROLL:       {{"PMNT", "RCDT", "SALA"}, {"PMNT", "ICDT", "SALA"}},
+	CONCEPT_STAMP_DUTIES:  {{"ACMT", "MCOP", "FEES"}, {"ACMT", "MDOP", "FEES"}},
+	CONCEPT_INTEREST_FEES: {{"ACMT", "MCOP", "INTR"}, {"ACMT", "MDOP", "FEES"}},
+	CONCEPT_CANCELLATIONS: {{"ACMT", "MCOP", "ADJT"}, {"ACMT", "MDOP", "ADJT"}},
+}
+
+// camtDocument holds the subset of the camt.053 message shared by the
+// camt.053.001.02 and camt.053.001.08 schemas.
+type camtDocument struct {
+	XMLName xml.Name          `xml:"Document"`
+	Xmlns   string            `xml:"xmlns,attr,omitempty"`
+	Stmts   camtBkToCstmrStmt `xml:"BkToCstmrStmt"`
+}
+
+type camtBkToCstmrStmt struct {
+	GrpHdr camtGrpHdr  `xml:"GrpHdr"`
+	Stmt   []*camtStmt `xml:"Stmt"`
+}
+
+type camtGrpHdr struct {
+	MsgId   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type camtStmt struct {
+	Id        string         `xml:"Id"`
+	CreDtTm   string         `xml:"CreDtTm"`
+	FrToDt    *camtFrToDt    `xml:"FrToDt,omitempty"`
+	Acct      camtAcct       `xml:"Acct"`
+	Bal       []camtBal      `xml:"Bal"`
+	TxsSummry *camtTxsSummry `xml:"TxsSummry,omitempty"`
+	Ntry      []*camtNtry    `xml:"Ntry"`
+}
+
+type camtFrToDt struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+type camtAcct struct {
+	Id struct {
+		IBAN string    `xml:"IBAN,omitempty"`
+		Othr *camtOthr `xml:"Othr,omitempty"`
+	} `xml:"Id"`
+	Ccy string `xml:"Ccy,omitempty"`
+	Nm  string `xml:"Nm,omitempty"`
+}
+
+type camtOthr struct {
+	Id string `xml:"Id"`
+}
+
+type camtBal struct {
+	Tp struct {
+		CdOrPrtry struct {
+			Cd string `xml:"Cd"`
+		} `xml:"CdOrPrtry"`
+	} `xml:"Tp"`
+	Amt       camtAmt `xml:"Amt"`
+	CdtDbtInd string  `xml:"CdtDbtInd"`
+	Dt        camtDt  `xml:"Dt"`
+}
+
+type camtAmt struct {
+	Value string `xml:",chardata"`
+	Ccy   string `xml:"Ccy,attr"`
+}
+
+type camtDt struct {
+	Dt   string `xml:"Dt,omitempty"`
+	DtTm string `xml:"DtTm,omitempty"`
+}
+
+type camtTxsSummry struct {
+	TtlNtries struct {
+		NbOfNtries string `xml:"NbOfNtries"`
+	} `xml:"TtlNtries"`
+	TtlCdtNtries camtNumberAndSum `xml:"TtlCdtNtries"`
+	TtlDbtNtries camtNumberAndSum `xml:"TtlDbtNtries"`
+}
+
+type camtNumberAndSum struct {
+	NbOfNtries string `xml:"NbOfNtries"`
+	Sum        string `xml:"Sum"`
+}
+
+type camtNtry struct {
+	NtryRef      string        `xml:"NtryRef,omitempty"`
+	Amt          camtAmt       `xml:"Amt"`
+	CdtDbtInd    string        `xml:"CdtDbtInd"`
+	Sts          camtStatus    `xml:"Sts"`
+	BookgDt      camtDt        `xml:"BookgDt"`
+	ValDt        camtDt        `xml:"ValDt"`
+	AcctSvcrRef  string        `xml:"AcctSvcrRef,omitempty"`
+	BkTxCd       camtBkTxCd    `xml:"BkTxCd"`
+	NtryDtls     *camtNtryDtls `xml:"NtryDtls,omitempty"`
+	AddtlNtryInf string        `xml:"AddtlNtryInf,omitempty"`
+}
+
+type camtBkTxCd struct {
+	Domn  *camtDomn  `xml:"Domn,omitempty"`
+	Prtry *camtPrtry `xml:"Prtry,omitempty"`
+}
+
+type camtDomn struct {
+	Cd   string `xml:"Cd"`
+	Fmly struct {
+		Cd        string `xml:"Cd"`
+		SubFmlyCd string `xml:"SubFmlyCd"`
+	} `xml:"Fmly"`
+}
+
+type camtPrtry struct {
+	Cd   string `xml:"Cd"`
+	Issr string `xml:"Issr,omitempty"`
+}
+
+type camtNtryDtls struct {
+	TxDtls []camtTxDtls `xml:"TxDtls"`
+}
+
+type camtTxDtls struct {
+	RmtInf *camtRmtInf `xml:"RmtInf,omitempty"`
+}
+
+type camtRmtInf struct {
+	Ustrd []string `xml:"Ustrd"`
+}
+
+// CAMT053Reader reads an ISO 20022 camt.053 bank to customer statement into
+// a Norma43 document with one account per statement.
+type CAMT053Reader struct {
+	r io.Reader
+}
+
+// camtStatus is the entry status, a plain code in camt.053.001.02 and a
+// code element in later versions.
+type camtStatus struct {
+	Cd     string
+	nested bool
+}
+
+func (s camtStatus) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if s.nested {
+		return e.EncodeElement(struct {
+			Cd string `xml:"Cd"`
+		}{s.Cd}, start)
+	}
+	return e.EncodeElement(s.Cd, start)
+}
+
+func (s *camtStatus) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var v struct {
+		Text string `xml:",chardata"`
+		Cd   string `xml:"Cd"`
+	}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+
+	s.Cd = strings.TrimSpace(v.Text)
+	if v.Cd != "" {
+		s.Cd = v.Cd
+		s.nested = true
+	}
+	return nil
+}
+
+func NewCAMT053Writer(w io.Writer, camtOptions *CAMT053Options) *CAMT053Writer {
+	co := new(CAMT053Options)
+
+	co.Version = CAMT053_V02
+	co.CreationTime = time.Now().UTC()
+
+	if camtOptions != nil {
+		if camtOptions.Version != "" {
+			co.Version = camtOptions.Version
+		}
+		co.MessageID = camtOptions.MessageID
+		if !camtOptions.CreationTime.IsZero() {
+			co.CreationTime = camtOptions.CreationTime
+		}
+	}
+
+	return &CAMT053Writer{
+		w:          w,
+		camtOption: co,
+	}
+}
+
+// CAMT053TransactionCode returns the ISO 20022 bank transaction domain,
+// family and sub family codes of a movement, derived from its common concept
+// code and the sign of its amount.
+func CAMT053TransactionCode(m *Movement) (string, string, string) {
+	idx := 0
+	if m.Amount < 0 {
+		idx = 1
+	}
+
+	if codes, ok := camtTransactionCodes[m.CommonConcept]; ok {
+		return codes[idx][0], codes[idx][1], codes[idx][2]
+	}
+
+	if idx == 0 {
+		return "PMNT", "MCOP", "OTHR"
+	}
+	return "PMNT", "MDOP", "OTHR"
+}
+
+func (c *CAMT053Writer) Write(n *Norma43) error {
+	if c.camtOption.Version != CAMT053_V02 && c.camtOption.Version != CAMT053_V08 {
+		return errors.New("unsupported camt.053 version " + string(c.camtOption.Version))
+	}
+
+	created := c.camtOption.CreationTime.Format("2006-01-02T15:04:05")
+
+	doc := camtDocument{Xmlns: string(c.camtOption.Version)}
+	doc.Stmts.GrpHdr.MsgId = c.camtOption.MessageID
+	doc.Stmts.GrpHdr.CreDtTm = created
+
+	for _, account := range n.Accounts {
+		stmt := c.statement(account)
+		stmt.CreDtTm = created
+		doc.Stmts.Stmt = append(doc.Stmts.Stmt, stmt)
+	}
+
+	if doc.Stmts.GrpHdr.MsgId == "" {
+		doc.Stmts.GrpHdr.MsgId = "N43"
+		if len(doc.Stmts.Stmt) > 0 {
+			doc.Stmts.GrpHdr.MsgId = doc.Stmts.Stmt[0].Id
+		}
+	}
+
+	if _, err := io.WriteString(c.w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(c.w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(c.w, "\n")
+	return err
+}
+
+func (c *CAMT053Writer) statement(account *Account) *camtStmt {
+	h := account.Header
+	if h == nil {
+		h = new(Header)
+	}
+
+	ccy := CurrencyCode(h.Currency)
+
+	stmt := new(camtStmt)
+	stmt.Id = h.BankCode + h.BranchCode + h.AccountNumber + "-" + h.EndDate.Format("20060102")
+	stmt.FrToDt = &camtFrToDt{
+		FrDtTm: h.StartDate.Format("2006-01-02T15:04:05"),
+		ToDtTm: h.EndDate.Format("2006-01-02T15:04:05"),
+	}
+	stmt.Acct.Id.IBAN = h.IBAN()
+	stmt.Acct.Ccy = ccy
+	stmt.Acct.Nm = strings.TrimSpace(h.AccountName)
+
+	stmt.Bal = append(stmt.Bal,
+		camtBalance("OPBD", h.InitialBalance, ccy, h.StartDate),
+		camtBalance("CLBD", accountFinalBalance(account), ccy, h.EndDate),
+	)
+
+	if f := account.Footer; f != nil {
+		stmt.TxsSummry = new(camtTxsSummry)
+		stmt.TxsSummry.TtlNtries.NbOfNtries = strconv.Itoa(f.CreditEntries + f.DebitEntries)
+		stmt.TxsSummry.TtlCdtNtries.NbOfNtries = strconv.Itoa(f.CreditEntries)
+		stmt.TxsSummry.TtlCdtNtries.Sum = camtAmount(f.CreditAmount)
+		stmt.TxsSummry.TtlDbtNtries.NbOfNtries = strconv.Itoa(f.DebitEntries)
+		stmt.TxsSummry.TtlDbtNtries.Sum = camtAmount(f.DebitAmount)
+	}
+
+	for _, m := range account.Movements {
+		stmt.Ntry = append(stmt.Ntry, c.entry(m, ccy))
+	}
+
+	return stmt
+}
+
+func (c *CAMT053Writer) entry(m *Movement, ccy string) *camtNtry {
+	ntry := new(camtNtry)
+
+	if doc := strings.TrimSpace(m.DocumentNumber); strings.Trim(doc, "0") != "" {
+		ntry.NtryRef = doc
+	}
+	ntry.Amt = camtAmt{Value: camtAmount(m.Amount), Ccy: ccy}
+	ntry.CdtDbtInd = camtIndicator(m.Amount)
+	ntry.Sts = camtStatus{Cd: "BOOK", nested: c.camtOption.Version != CAMT053_V02}
+	ntry.BookgDt.Dt = m.TransactionDate.Format("2006-01-02")
+	ntry.ValDt.Dt = m.ValueDate.Format("2006-01-02")
+	ntry.AcctSvcrRef = strings.TrimSpace(m.Description)
+
+	domain, family, subFamily := CAMT053TransactionCode(m)
+	ntry.BkTxCd.Domn = &camtDomn{Cd: domain}
+	ntry.BkTxCd.Domn.Fmly.Cd = family
+	ntry.BkTxCd.Domn.Fmly.SubFmlyCd = subFamily
+	if m.CommonConcept != "" {
+		ntry.BkTxCd.Prtry = &camtPrtry{Cd: m.CommonConcept + m.OwnConcept, Issr: "AEB"}
+	}
+
+	if concepts := m.ComplementaryConcepts(); len(concepts) > 0 {
+		ntry.NtryDtls = &camtNtryDtls{
+			TxDtls: []camtTxDtls{{RmtInf: &camtRmtInf{Ustrd: concepts}}},
+		}
+	}
+
+	return ntry
+}
+
+func camtBalance(code string, amount float64, ccy string, date time.Time) camtBal {
+	var bal camtBal
+
+	bal.Tp.CdOrPrtry.Cd = code
+	bal.Amt = camtAmt{Value: camtAmount(amount), Ccy: ccy}
+	bal.CdtDbtInd = camtIndicator(amount)
+	bal.Dt.Dt = date.Format("2006-01-02")
+
+	return bal
+}
+
+func camtAmount(amount float64) string {
+	if amount < 0 {
+		amount = -amount
+	}
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func camtIndicator(amount float64) string {
+	if amount < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+func NewCAMT053Reader(r io.Reader) *CAMT053Reader {
+	return &CAMT053Reader{r: r}
+}
+
+// Parse decodes the camt.053 message. Pending and informative entries are
+// left out, only booked entries become movements.
+func (c *CAMT053Reader) Parse() (*Norma43, error) {
+	n := new(Norma43)
+
+	var doc camtDocument
+	if err := xml.NewDecoder(c.r).Decode(&doc); err != nil {
+		return n, err
+	}
+
+	if !strings.HasPrefix(doc.XMLName.Space, "urn:iso:std:iso:20022:tech:xsd:camt.053.") {
+		return n, errors.New("not a camt.053 document")
+	}
+
+	for _, stmt := range doc.Stmts.Stmt {
+		account, err := camtAccount(stmt)
+		if err != nil {
+			return n, err
+		}
+		n.Accounts = append(n.Accounts, account)
+
+		n.ReportedEntries += account.Records()
+	}
+
+	return n, nil
+}
+
+func camtAccount(stmt *camtStmt) (*Account, error) {
+	var err error
+
+	account := new(Account)
+
+	h := new(Header)
+	h.BankCode, h.BranchCode, h.AccountNumber = camtAccountID(stmt.Acct)
+	h.Currency = CurrencyNumber(stmt.Acct.Ccy)
+	h.InformationModeCode = "3"
+	h.AccountName = stmt.Acct.Nm
+	account.Header = h
+
+	var opening, closing *camtBal
+	for i, bal := range stmt.Bal {
+		switch bal.Tp.CdOrPrtry.Cd {
+		case "OPBD", "PRCD":
+			if opening == nil {
+				opening = &stmt.Bal[i]
+			}
+		case "CLBD":
+			closing = &stmt.Bal[i]
+		}
+	}
+
+	if stmt.FrToDt != nil {
+		if h.StartDate, err = camtDate(camtDt{DtTm: stmt.FrToDt.FrDtTm}); err != nil {
+			return account, err
+		}
+		if h.EndDate, err = camtDate(camtDt{DtTm: stmt.FrToDt.ToDtTm}); err != nil {
+			return account, err
+		}
+	}
+
+	for _, ntry := range stmt.Ntry {
+		if ntry.Sts.Cd != "" && ntry.Sts.Cd != "BOOK" {
+			continue
+		}
+
+		m, err := camtMovement(ntry)
+		if err != nil {
+			return account, err
+		}
+		account.Movements = append(account.Movements, m)
+	}
+
+	sum := float64(0)
+	for _, m := range account.Movements {
+		sum += m.Amount
+	}
+
+	if opening != nil {
+		if h.InitialBalance, err = camtBalanceAmount(opening); err != nil {
+			return account, err
+		}
+		if h.StartDate.IsZero() {
+			h.StartDate, _ = camtDate(opening.Dt)
+		}
+	} else if closing != nil {
+		final, err := camtBalanceAmount(closing)
+		if err != nil {
+			return account, err
+		}
+		h.InitialBalance = roundAmount(final - sum)
+	}
+
+	if h.EndDate.IsZero() && closing != nil {
+		h.EndDate, _ = camtDate(closing.Dt)
+	}
+
+	if h.StartDate.IsZero() || h.EndDate.IsZero() {
+		for _, m := range account.Movements {
+			if h.StartDate.IsZero() || m.TransactionDate.Before(h.StartDate) {
+				h.StartDate = m.TransactionDate
+			}
+			if m.TransactionDate.After(h.EndDate) {
+				h.EndDate = m.TransactionDate
+			}
+		}
+	}
+
+	balance := h.InitialBalance
+	for _, m := range account.Movements {
+		balance += m.Amount
+		m.Balance = balance
+		m.FilteredSum = balance - h.InitialBalance
+	}
+
+	account.Footer = account.ComputeFooter()
+	if closing != nil {
+		if account.Footer.FinalBalance, err = camtBalanceAmount(closing); err != nil {
+			return account, err
+		}
+	}
+
+	return account, nil
+}
+
+func camtMovement(ntry *camtNtry) (*Movement, error) {
+	var err error
+
+	m := new(Movement)
+
+	if m.TransactionDate, err = camtDate(ntry.BookgDt); err != nil {
+		return m, err
+	}
+	if m.ValueDate, err = camtDate(ntry.ValDt); err != nil {
+		m.ValueDate = m.TransactionDate
+	}
+
+	amount, err := strconv.ParseFloat(strings.TrimSpace(ntry.Amt.Value), 64)
+	if err != nil {
+		return m, err
+	}
+	if ntry.CdtDbtInd == "DBIT" {
+		amount = -amount
+	}
+	m.Amount = amount
+
+	m.CommonConcept, m.OwnConcept = camtConcept(ntry.BkTxCd, amount)
+	m.DocumentNumber = ntry.NtryRef
+	m.Description = ntry.AcctSvcrRef
+
+	concepts := []string{}
+	if ntry.NtryDtls != nil {
+		for _, tx := range ntry.NtryDtls.TxDtls {
+			if tx.RmtInf != nil {
+				concepts = append(concepts, tx.RmtInf.Ustrd...)
+			}
+		}
+	}
+	if len(concepts) == 0 && ntry.AddtlNtryInf != "" {
+		concepts = append(concepts, ntry.AddtlNtryInf)
+	}
+	m.ExtraInformation = extraInformation(concepts)
+
+	return m, nil
+}
+
+func camtAccountID(acct camtAcct) (string, string, string) {
+	id := acct.Id.IBAN
+	if id == "" && acct.Id.Othr != nil {
+		id = acct.Id.Othr.Id
+	}
+	return SplitAccountID(id)
+}
+
+// camtConcept returns the AEB common and own concept codes of an entry,
+// either from the AEB proprietary code or guessed from the ISO 20022 bank
+// transaction code.
+func camtConcept(code camtBkTxCd, amount float64) (string, string) {
+	if code.Prtry != nil && code.Prtry.Issr == "AEB" && len(code.Prtry.Cd) == 5 {
+		return code.Prtry.Cd[:2], code.Prtry.Cd[2:]
+	}
+
+	if code.Domn != nil {
+		idx := 0
+		if amount < 0 {
+			idx = 1
+		}
+
+		concepts := make([]string, 0, len(camtTransactionCodes))
+		for concept := range camtTransactionCodes {
+			concepts = append(concepts, concept)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(concepts)))
+
+		for _, concept := range concepts {
+			codes := camtTransactionCodes[concept][idx]
+			if codes[0] == code.Domn.Cd && codes[1] == code.Domn.Fmly.Cd && codes[2] == code.Domn.Fmly.SubFmlyCd {
+				return concept, "000"
+			}
+		}
+	}
+
+	return CONCEPT_MISCELLANEOUS, "000"
+}
+
+func camtBalanceAmount(bal *camtBal) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(bal.Amt.Value), 64)
+	if err != nil {
+		return 0, err
+	}
+	if bal.CdtDbtInd == "DBIT" {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func camtDate(dt camtDt) (time.Time, error) {
+	date := dt.Dt
+	if date == "" {
+		date = dt.DtTm
+	}
+	if len(date) < 10 {
+		return time.Time{}, errors.New("wrong date format")
+	}
+	return time.Parse("2006-01-02", date[:10])
+}
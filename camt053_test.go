@@ -0,0 +1,190 @@
+package n43
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_camt053(t *testing.T) {
+	for _, version := range []CAMT053Version{CAMT053_V02, CAMT053_V08} {
+		var buf bytes.Buffer
+		ops := &CAMT053Options{Version: version, CreationTime: time.Date(2020, 2, 11, 8, 0, 0, 0, time.UTC)}
+		if err := NewCAMT053Writer(&buf, ops).Write(parseSample(t)); err != nil {
+			t.Fatal(err)
+		}
+
+		out := buf.String()
+		if !strings.Contains(out, `<Document xmlns="`+string(version)+`">`) {
+			t.Errorf("Expected %s namespace, but %s found", version, out)
+		}
+
+		var doc camtDocument
+		if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(doc.Stmts.Stmt) != 1 {
+			t.Fatalf("Expected 1 statement, but %d found", len(doc.Stmts.Stmt))
+		}
+
+		stmt := doc.Stmts.Stmt[0]
+		if stmt.Acct.Id.IBAN != "ES4811112222033333444412" {
+			t.Errorf("Expected IBAN ES4811112222033333444412, but %s found", stmt.Acct.Id.IBAN)
+		}
+
+		if len(stmt.Bal) != 2 || stmt.Bal[0].Tp.CdOrPrtry.Cd != "OPBD" || stmt.Bal[0].Amt.Value != "2463.43" || stmt.Bal[1].Tp.CdOrPrtry.Cd != "CLBD" || stmt.Bal[1].Amt.Value != "2301.59" {
+			t.Errorf("Expected OPBD 2463.43 and CLBD 2301.59 balances, but %+v found", stmt.Bal)
+		}
+
+		if len(stmt.Ntry) != 5 {
+			t.Fatalf("Expected 5 entries, but %d found", len(stmt.Ntry))
+		}
+
+		ntry := stmt.Ntry[0]
+		if ntry.Amt.Value != "23.99" || ntry.Amt.Ccy != "EUR" || ntry.CdtDbtInd != "DBIT" {
+			t.Errorf("Expected a 23.99 EUR debit entry, but %+v found", ntry)
+		}
+
+		if ntry.Sts.Cd != "BOOK" || ntry.Sts.nested != (version == CAMT053_V08) {
+			t.Errorf("Expected BOOK status in %s format, but %+v found", version, ntry.Sts)
+		}
+
+		if ntry.BkTxCd.Domn == nil || ntry.BkTxCd.Domn.Cd != "PMNT" || ntry.BkTxCd.Domn.Fmly.Cd != "CCRD" || ntry.BkTxCd.Domn.Fmly.SubFmlyCd != "POSD" {
+			t.Errorf("Expected PMNT/CCRD/POSD bank transaction code, but %+v found", ntry.BkTxCd.Domn)
+		}
+
+		if ntry.NtryDtls == nil || len(ntry.NtryDtls.TxDtls[0].RmtInf.Ustrd) != 2 {
+			t.Errorf("Expected remittance information from the extra information, but %+v found", ntry.NtryDtls)
+		}
+	}
+}
+
+func Test_camt053Reader(t *testing.T) {
+	in := parseSample(t)
+
+	var buf bytes.Buffer
+	if err := NewCAMT053Writer(&buf, &CAMT053Options{Version: CAMT053_V08}).Write(in); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := NewCAMT053Reader(&buf).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out.Accounts) != 1 {
+		t.Fatalf("Expected 1 account, but %d found", len(out.Accounts))
+	}
+
+	h := out.Accounts[0].Header
+	if h.BankCode != "1111" || h.BranchCode != "2222" || h.AccountNumber != "3333444412" || h.Currency != "978" {
+		t.Errorf("Expected account 1111 2222 3333444412 in 978, but %+v found", h)
+	}
+
+	if !h.StartDate.Equal(in.Accounts[0].Header.StartDate) || !h.EndDate.Equal(in.Accounts[0].Header.EndDate) {
+		t.Errorf("Expected the statement dates, but %s - %s found", h.StartDate, h.EndDate)
+	}
+
+	if h.InitialBalance != 2463.43 {
+		t.Errorf("Expected InitialBalance to be 2463.43, but %.2f found", h.InitialBalance)
+	}
+
+	if out.Accounts[0].Footer.FinalBalance != 2301.59 {
+		t.Errorf("Expected FinalBalance to be 2301.59, but %.2f found", out.Accounts[0].Footer.FinalBalance)
+	}
+
+	for i, m := range out.Accounts[0].Movements {
+		e := in.Accounts[0].Movements[i]
+		if m.Amount != e.Amount || !m.TransactionDate.Equal(e.TransactionDate) || !m.ValueDate.Equal(e.ValueDate) {
+			t.Errorf("Expected movement %d to be %+v, but %+v found", i, e, m)
+		}
+		if m.CommonConcept != e.CommonConcept || m.OwnConcept != e.OwnConcept {
+			t.Errorf("Expected concepts %s %s in movement %d, but %s %s found", e.CommonConcept, e.OwnConcept, i, m.CommonConcept, m.OwnConcept)
+		}
+		if strings.Join(m.ExtraInformation, "|") != strings.Join(e.ExtraInformation, "|") {
+			t.Errorf("Expected extra information %q in movement %d, but %q found", e.ExtraInformation, i, m.ExtraInformation)
+		}
+		if fmt.Sprintf("%.2f", m.Balance) != fmt.Sprintf("%.2f", e.Balance) {
+			t.Errorf("Expected Balance %.2f in movement %d, but %.2f found", e.Balance, i, m.Balance)
+		}
+	}
+}
+
+func Test_camt053ReaderBank(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>MSG1</MsgId><CreDtTm>2023-05-02T06:00:00</CreDtTm></GrpHdr>
+    <Stmt>
+      <Id>STMT1</Id>
+      <Acct><Id><IBAN>ES9121000418450200051332</IBAN></Id><Ccy>EUR</Ccy></Acct>
+      <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">90.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2023-05-01</Dt></Dt></Bal>
+      <Ntry>
+        <Amt Ccy="EUR">10.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><DtTm>2023-05-01T10:20:00</DtTm></BookgDt><ValDt><Dt>2023-05-01</Dt></ValDt>
+        <BkTxCd><Domn><Cd>PMNT</Cd><Fmly><Cd>IDDT</Cd><SubFmlyCd>ESDD</SubFmlyCd></Fmly></Domn></BkTxCd>
+        <AddtlNtryInf>RECIBO TELEFONIA</AddtlNtryInf>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">5.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts><Cd>PDNG</Cd></Sts>
+        <BookgDt><Dt>2023-05-01</Dt></BookgDt>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+	out, err := NewCAMT053Reader(strings.NewReader(data)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account := out.Accounts[0]
+	if account.Header.BankCode != "2100" || account.Header.BranchCode != "0418" || account.Header.AccountNumber != "0200051332" {
+		t.Errorf("Expected account 2100 0418 0200051332, but %+v found", account.Header)
+	}
+
+	if account.Header.InitialBalance != 100 {
+		t.Errorf("Expected InitialBalance to be 100.00, but %.2f found", account.Header.InitialBalance)
+	}
+
+	if len(account.Movements) != 1 {
+		t.Fatalf("Expected 1 booked movement, but %d found", len(account.Movements))
+	}
+
+	m := account.Movements[0]
+	if m.Amount != -10 || m.CommonConcept != CONCEPT_DIRECT_DEBITS || m.ExtraInformation[0] != "RECIBO TELEFONIA" {
+		t.Errorf("Expected a -10.00 direct debit, but %+v found", m)
+	}
+}
+
+func Test_camt053Schema(t *testing.T) {
+	// The CI installs xmllint, so the schemas are always checked there.
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil && os.Getenv("CI") != "" {
+		t.Fatal("xmllint not found, it is required in CI")
+	}
+	if err != nil {
+		t.Skip("xmllint not found, the camt.053 schemas are not checked")
+	}
+
+	for _, version := range []CAMT053Version{CAMT053_V02, CAMT053_V08} {
+		var buf bytes.Buffer
+		if err := NewCAMT053Writer(&buf, &CAMT053Options{Version: version}).Write(parseSample(t)); err != nil {
+			t.Fatal(err)
+		}
+
+		schema := filepath.Join("testdata", strings.TrimPrefix(string(version), "urn:iso:std:iso:20022:tech:xsd:")+".xsd")
+		cmd := exec.Command(xmllint, "--noout", "--schema", schema, "-")
+		cmd.Stdin = &buf
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("Expected a valid %s document, but %s found", version, out)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package n43
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Categorizer assigns a budget category and tags to movements following a
+// list of rules, usually read from a JSON or YAML file with LoadCategorizer:
+//
+//	{
+//	  "rules": [
+//	    {"category": "Groceries", "concepts": ["12"], "complementary_concept": "SUPERMERCADO|MERCADONA"},
+//	    {"category": "Salary", "type": "credit", "concepts": ["15"], "tags": ["income"]},
+//	    {"category": "Large purchases", "type": "debit", "min_amount": 500, "priority": 10},
+//	    {"category": "Other", "fallback": true}
+//	  ]
+//	}
+//
+// or the same rules in YAML:
+//
+//	rules:
+//	  - category: Groceries
+//	    concepts: ["12"]
+//	    complementary_concept: SUPERMERCADO|MERCADONA
+//	  - category: Other
+//	    fallback: true
+//
+// A Categorizer is not modified once created and can be shared.
+type Categorizer struct {
+	// rules are compiled copies of the rules, sorted in the order they are
+	// tried.
+	rules []*CategoryRule
+}
+
+// CategoryRule matches movements by their common concept codes, the sign and
+// the absolute value of their amount, and regular expressions run against
+// their references and complementary concepts. Empty fields match every
+// movement.
+//
+// Rules are tried by descending Priority, and in order for the same
+// priority; the first one matching wins. Fallback rules are only tried when
+// no other rule matches.
+type CategoryRule struct {
+	Category string   `json:"category" yaml:"category"`
+	Tags     []string `json:"tags" yaml:"tags"`
+	Priority int      `json:"priority" yaml:"priority"`
+	Fallback bool     `json:"fallback" yaml:"fallback"`
+	Concepts []string `json:"concepts" yaml:"concepts"`
+	// Type is debit or credit.
+	Type      string   `json:"type" yaml:"type"`
+	MinAmount *float64 `json:"min_amount" yaml:"min_amount"`
+	MaxAmount *float64 `json:"max_amount" yaml:"max_amount"`
+	// Description is matched against the references of the movement and
+	// ComplementaryConcept against each of its complementary concepts.
+	Description          string `json:"description" yaml:"description"`
+	ComplementaryConcept string `json:"complementary_concept" yaml:"complementary_concept"`
+	descRe               *regexp.Regexp
+	conceptRe            *regexp.Regexp
+}
+
+// categoryRules is the layout of the rule files.
+type categoryRules struct {
+	Rules []*CategoryRule `json:"rules" yaml:"rules"`
+}
+
+// LoadCategorizer reads categorization rules in JSON, or in YAML when the
+// document is not a JSON object, and compiles them.
+func LoadCategorizer(r io.Reader) (*Categorizer, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	file := new(categoryRules)
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err = json.Unmarshal(data, file)
+	} else {
+		err = yaml.Unmarshal(data, file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCategorizer(file.Rules)
+}
+
+// NewCategorizer returns a Categorizer with copies of the rules, failing when
+// any of them is invalid.
+func NewCategorizer(rules []*CategoryRule) (*Categorizer, error) {
+	c := &Categorizer{rules: make([]*CategoryRule, 0, len(rules))}
+
+	for i, rule := range rules {
+		if rule.Category == "" {
+			return nil, errors.New("category rule " + strconv.Itoa(i) + " has no category")
+		}
+		if rule.Type != "" && rule.Type != "debit" && rule.Type != "credit" {
+			return nil, errors.New("category rule " + strconv.Itoa(i) + " has an invalid type " + rule.Type)
+		}
+
+		r := *rule
+		if r.Description != "" {
+			re, err := regexp.Compile(r.Description)
+			if err != nil {
+				return nil, err
+			}
+			r.descRe = re
+		}
+		if r.ComplementaryConcept != "" {
+			re, err := regexp.Compile(r.ComplementaryConcept)
+			if err != nil {
+				return nil, err
+			}
+			r.conceptRe = re
+		}
+		c.rules = append(c.rules, &r)
+	}
+
+	// Fallback rules last, then by priority, keeping the order of the file.
+	sort.SliceStable(c.rules, func(i, j int) bool {
+		if c.rules[i].Fallback != c.rules[j].Fallback {
+			return !c.rules[i].Fallback
+		}
+		return c.rules[i].Priority > c.rules[j].Priority
+	})
+
+	return c, nil
+}
+
+// Match returns the rule categorizing m, or nil when none matches.
+func (c *Categorizer) Match(m *Movement) *CategoryRule {
+	concepts := m.ComplementaryConcepts()
+	for _, rule := range c.rules {
+		if rule.matches(m, concepts) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Categorize sets the Category and Tags of every movement of the document.
+// Movements no rule matches are left without category.
+func (c *Categorizer) Categorize(n *Norma43) {
+	for _, account := range n.Accounts {
+		for _, m := range account.Movements {
+			m.Category, m.Tags = "", nil
+			if rule := c.Match(m); rule != nil {
+				m.Category = rule.Category
+				m.Tags = append([]string{}, rule.Tags...)
+			}
+		}
+	}
+}
+
+func (rule *CategoryRule) matches(m *Movement, concepts []string) bool {
+	if len(rule.Concepts) > 0 && !containsString(rule.Concepts, m.CommonConcept) {
+		return false
+	}
+	if rule.Type == "debit" && m.Amount >= 0 || rule.Type == "credit" && m.Amount < 0 {
+		return false
+	}
+	if rule.MinAmount != nil && abs(m.Amount) < *rule.MinAmount {
+		return false
+	}
+	if rule.MaxAmount != nil && abs(m.Amount) > *rule.MaxAmount {
+		return false
+	}
+	if rule.descRe != nil && !rule.descRe.MatchString(strings.TrimSpace(m.Description)) {
+		return false
+	}
+	if rule.conceptRe != nil && !matchAny(rule.conceptRe, concepts) {
+		return false
+	}
+	return true
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
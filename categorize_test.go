@@ -0,0 +1,124 @@
+package n43
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleCategories = `{
+  "rules": [
+    {"category": "Other", "fallback": true},
+    {"category": "Shopping", "concepts": ["12"], "type": "debit"},
+    {"category": "Insurance", "complementary_concept": "INSURANCE", "description": "^AHSOW", "tags": ["bills", "home"]},
+    {"category": "Refunds", "concepts": ["12"], "type": "credit"},
+    {"category": "Small", "type": "debit", "max_amount": 5, "priority": 5}
+  ]
+}`
+
+func Test_categorize(t *testing.T) {
+	c, err := LoadCategorizer(strings.NewReader(sampleCategories))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := parseSample(t)
+	c.Categorize(in)
+
+	expected := []string{"Shopping", "Insurance", "Other", "Refunds", "Small"}
+	for i, m := range in.Accounts[0].Movements {
+		if m.Category != expected[i] {
+			t.Errorf("Expected movement %d in %s, but %s found", i, expected[i], m.Category)
+		}
+	}
+	if tags := in.Accounts[0].Movements[1].Tags; !reflect.DeepEqual(tags, []string{"bills", "home"}) {
+		t.Errorf("Expected the tags of the rule, but %v found", tags)
+	}
+
+	if rule := c.Match(in.Accounts[0].Movements[4]); rule == nil || rule.Category != "Small" {
+		t.Errorf("Expected the rule with the highest priority, but %v found", rule)
+	}
+
+	totals := Summarize([]*Norma43{in}, nil).Totals
+	if len(totals.ByCategory) != 5 || totals.ByCategory[1].Key != "Other" || totals.ByCategory[1].Net != -70.29 {
+		t.Errorf("Expected the totals by category, but %+v found", totals.ByCategory)
+	}
+	if totals := Summarize([]*Norma43{parseSample(t)}, nil).Totals; totals.ByCategory != nil {
+		t.Errorf("Expected no totals by category without categories")
+	}
+}
+
+func Test_loadCategorizerYAML(t *testing.T) {
+	c, err := LoadCategorizer(strings.NewReader(`
+rules:
+  - category: Other
+    fallback: true
+  - category: Insurance
+    complementary_concept: INSURANCE
+    tags: [bills, home]
+  - category: Small
+    type: debit
+    max_amount: 5
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := parseSample(t)
+	c.Categorize(in)
+
+	expected := []string{"Other", "Insurance", "Insurance", "Other", "Small"}
+	for i, m := range in.Accounts[0].Movements {
+		if m.Category != expected[i] {
+			t.Errorf("Expected movement %d in %s, but %s found", i, expected[i], m.Category)
+		}
+	}
+	if tags := in.Accounts[0].Movements[1].Tags; !reflect.DeepEqual(tags, []string{"bills", "home"}) {
+		t.Errorf("Expected the tags of the rule, but %v found", tags)
+	}
+}
+
+func Test_newCategorizer(t *testing.T) {
+	rules := []*CategoryRule{
+		{Category: "Other", Fallback: true},
+		{Category: "Insurance", Description: "^AHSOW"},
+	}
+
+	c, err := NewCategorizer(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].Category != "Other" || rules[1].descRe != nil {
+		t.Errorf("Expected the rules given to be left as they are, but %+v found", rules)
+	}
+
+	// Compiled once, the categorizer can be shared.
+	done := make(chan bool)
+	for i := 0; i < 4; i++ {
+		go func(n *Norma43) {
+			c.Categorize(n)
+			done <- true
+		}(parseSample(t))
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+
+	if _, err := NewCategorizer([]*CategoryRule{{Category: "A", ComplementaryConcept: "["}}); err == nil {
+		t.Errorf("Expected an error for an invalid expression")
+	}
+}
+
+func Test_loadCategorizer(t *testing.T) {
+	for _, rules := range []string{
+		`{"rules": [{"concepts": ["12"]}]}`,
+		`{"rules": [{"category": "A", "type": "both"}]}`,
+		`{"rules": [{"category": "A", "description": "("}]}`,
+		"rules:\n  - category: A\n    type: both\n",
+		"rules: [",
+	} {
+		if _, err := LoadCategorizer(strings.NewReader(rules)); err == nil {
+			t.Errorf("Expected an error for %s", rules)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/Xumeiquer/n43"
+)
+
+// anonymizeCommand implements the anonymize command, which writes the input
+// documents with pseudonyms instead of their identifying data, ready to be
+// shared.
+func anonymizeCommand(args []string) int {
+	fs := newFlagSet("anonymize", "[files...]")
+
+	ops := parserFlags(fs)
+	in := fs.String("in", "", "Read from file.")
+	out := fs.String("o", "", "Write to file instead of the standard output.")
+	seed := fs.String("seed", "", "Seed of the pseudonyms, the same seed gives the same pseudonyms. Random by default.")
+	scale := fs.Float64("scale", 1, "Multiply every amount by this factor.")
+	crlf := fs.Bool("crlf", false, "End the records with CR LF.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *scale <= 0 {
+		return fail(errors.New("the scale must be greater than 0"))
+	}
+
+	files, err := inputFiles(*in, fs.Args())
+	if err != nil {
+		return fail(err)
+	}
+	docs, err := readDocuments(files, "n43", ops)
+	if err != nil {
+		return fail(err)
+	}
+
+	res, err := n43.Anonymize(combine(docs), &n43.AnonymizeOptions{Seed: *seed, Scale: *scale})
+	if err != nil {
+		return fail(err)
+	}
+
+	w, closeOutput, err := createOutput(*out)
+	if err != nil {
+		return fail(err)
+	}
+
+	err = n43.NewWriter(w, &n43.WriterOptions{CRLF: *crlf}).Write(res)
+	err = closeOutput(err)
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Xumeiquer/n43"
+)
+
+// continuityIssue is an issue with the names of the files of the statements
+// involved, as written by the json output.
+type continuityIssue struct {
+	*n43.ContinuityIssue
+	PreviousFile string `json:"previous_file"`
+	NextFile     string `json:"next_file"`
+}
+
+// continuityCommand implements the continuity command, which checks that the
+// statements of each account follow each other without gaps, overlaps or
+// balance jumps, and exits with exitFailure when they do not.
+func continuityCommand(args []string) int {
+	fs := newFlagSet("continuity", "files...")
+
+	ops := parserFlags(fs)
+	out := fs.String("o", "", "Write the report to file instead of the standard output.")
+	format := fs.String("format", "text", "Output format: text or json.")
+	ignoreWeekends := fs.Bool("ignoreWeekends", false, "Do not report gaps made only of Saturdays and Sundays.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	files, err := inputFiles("", fs.Args())
+	if err != nil {
+		return fail(err)
+	}
+	docs, err := readDocuments(files, "n43", ops)
+	if err != nil {
+		return fail(err)
+	}
+
+	res := []*n43.Norma43{}
+	names := map[*n43.Account]string{}
+	for _, doc := range docs {
+		res = append(res, doc.Norma43)
+		for _, account := range doc.Accounts {
+			names[account] = doc.name
+		}
+	}
+
+	issues := []*continuityIssue{}
+	for _, issue := range n43.CheckContinuity(res, &n43.ContinuityOptions{IgnoreWeekends: *ignoreWeekends}) {
+		issues = append(issues, &continuityIssue{
+			ContinuityIssue: issue,
+			PreviousFile:    names[issue.Previous],
+			NextFile:        names[issue.Next],
+		})
+	}
+
+	w, closeOutput, err := createOutput(*out)
+	if err != nil {
+		return fail(err)
+	}
+
+	switch *format {
+	case "text":
+		err = printContinuity(w, issues)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(issues)
+	default:
+		err = errors.New("unknown output format " + *format)
+	}
+	err = closeOutput(err)
+	if err != nil {
+		return fail(err)
+	}
+
+	if len(issues) > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func printContinuity(w io.Writer, issues []*continuityIssue) error {
+	for _, issue := range issues {
+		if _, err := fmt.Fprintf(w, "%s (%s, %s)\n", issue.Error(), issue.PreviousFile, issue.NextFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/Xumeiquer/n43"
+)
+
+type convertOptions struct {
+	to            string
+	ofxVersion    int
+	camtVersion   string
+	sqlDialect    string
+	mapping       string
+	qifDateFormat n43.QIFDateFormat
+}
+
+// convertCommand implements the convert command, which writes the input
+// documents in another format. Several inputs are written as a single
+// document with the accounts of all of them.
+func convertCommand(args []string) int {
+	fs := newFlagSet("convert", "-to format [files...]")
+
+	ops := parserFlags(fs)
+	co := &convertOptions{qifDateFormat: n43.QIF_DMY}
+	categories := categoriesFlag(fs)
+
+	in := fs.String("in", "", "Read from file.")
+	from := fs.String("from", "n43", "Input format: n43, camt053 or mt940.")
+	out := fs.String("o", "", "Write to file instead of the standard output.")
+	fs.StringVar(&co.to, "to", "", "Output format: n43, xlsx, ofx, qif, camt053, mt940, beancount, ledger or sql.")
+	fs.IntVar(&co.ofxVersion, "ofxVersion", int(n43.OFX_V2), "OFX version: 102 (SGML) or 220 (XML).")
+	fs.StringVar(&co.camtVersion, "camtVersion", "02", "camt.053 version: 02 or 08.")
+	fs.StringVar(&co.sqlDialect, "sqlDialect", string(n43.SQLITE), "SQL dialect: sqlite or postgres.")
+	fs.StringVar(&co.mapping, "mapping", "", "JSON file mapping accounts and movements to ledger accounts.")
+	fs.Var(&co.qifDateFormat, "qifDateFormat", "Order of the QIF dates: DMY, MDY or YMD.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if co.to == "" {
+		fs.Usage()
+		return exitError
+	}
+
+	files, err := inputFiles(*in, fs.Args())
+	if err != nil {
+		return fail(err)
+	}
+	docs, err := readDocuments(files, *from, ops)
+	if err != nil {
+		return fail(err)
+	}
+	if err := categorize(docs, *categories); err != nil {
+		return fail(err)
+	}
+
+	w, closeOutput, err := createOutput(*out)
+	if err != nil {
+		return fail(err)
+	}
+
+	err = convert(w, combine(docs), co)
+	err = closeOutput(err)
+	if err != nil {
+		return fail(errors.New("unable to convert to " + co.to + ". " + err.Error()))
+	}
+	return exitOK
+}
+
+func convert(w io.Writer, res *n43.Norma43, co *convertOptions) error {
+	ledgerOps := &n43.LedgerOptions{OpenAccounts: true}
+	if co.mapping != "" {
+		f, err := os.Open(co.mapping)
+		if err != nil {
+			return err
+		}
+		ledgerOps.Mapping, err = n43.LoadLedgerMapping(f)
+		f.Close()
+		if err != nil {
+			return errors.New("unable to load the mapping. " + err.Error())
+		}
+	}
+
+	switch co.to {
+	case "n43":
+		return n43.NewWriter(w, nil).Write(res)
+	case "xlsx":
+		return n43.NewXLSXWriter(w).Write(res)
+	case "ofx":
+		return n43.NewOFXWriter(w, &n43.OFXOptions{Version: n43.OFXVersion(co.ofxVersion)}).Write(res)
+	case "qif":
+		return n43.NewQIFWriter(w, &n43.QIFOptions{DateFormat: co.qifDateFormat}).Write(res)
+	case "camt053":
+		version := n43.CAMT053Version("urn:iso:std:iso:20022:tech:xsd:camt.053.001." + co.camtVersion)
+		return n43.NewCAMT053Writer(w, &n43.CAMT053Options{Version: version}).Write(res)
+	case "mt940":
+		return n43.NewMT940Writer(w).Write(res)
+	case "beancount":
+		return n43.NewBeancountWriter(w, ledgerOps).Write(res)
+	case "ledger", "hledger":
+		return n43.NewLedgerWriter(w, ledgerOps).Write(res)
+	case "sql":
+		return n43.NewSQLWriter(w, &n43.SQLOptions{Dialect: n43.SQLDialect(co.sqlDialect)}).Write(res)
+	}
+	return errors.New("unknown output format " + co.to)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Xumeiquer/n43"
+)
+
+type csvColumn func(h *n43.Header, m *n43.Movement, decimalComma bool) string
+
+var csvColumns = map[string]csvColumn{
+	"bank_code": func(h *n43.Header, m *n43.Movement, _ bool) string {
+		return h.BankCode
+	},
+	"account_branch_code": func(h *n43.Header, m *n43.Movement, _ bool) string {
+		return h.BranchCode
+	},
+	"account_number": func(h *n43.Header, m *n43.Movement, _ bool) string {
+		return h.AccountNumber
+	},
+	"account_name": func(h *n43.Header, m *n43.Movement, _ bool) string {
+		return strings.TrimSpace(h.AccountName)
+	},
+	"currency": func(h *n43.Header, m *n43.Movement, _ bool) string {
+		return h.Currency
+	},
+	"initial_balance": func(h *n43.Header, m *n43.Movement, decimalComma bool) string {
+		return formatCSVAmount(h.InitialBalance, decimalComma)
+	},
+	"branch_code": func(h *n43.Header, m *n43.Movement, _ bool) string {
+		return m.BranchCode
+	},
+	"transaction_date": func(h *n43.Header, m *n43.Movement, _ bool) string {
+		return m.TransactionDate.Format("2006-01-02")
+	},
+	"value_date": func(h *n43.Header, m *n43.Movement, _ bool) string {
+		return m.ValueDate.Format("2006-01-02")
+	},
+	"amount": func(h *n43.Header, m *n43.Movement, decimalComma bool) string {
+		return formatCSVAmount(m.Amount, decimalComma)
+	},
+	"balance": func(h *n43.Header, m *n43.Movement, decimalComma bool) string {
+		return formatCSVAmount(m.Balance, decimalComma)
+	},
+	"filtered_sum": func(h *n43.Header, m *n43.Movement, decimalComma bool) string {
+		return formatCSVAmount(m.FilteredSum, decimalComma)
+	},
+	"description": func(h *n43.Header, m *n43.Movement, _ bool) string {
+		return strings.TrimSpace(m.Description)
+	},
+	"extra_information": func(h *n43.Header, m *n43.Movement, _ bool) string {
+		lines := make([]string, 0, len(m.ExtraInformation))
+		for _, line := range m.ExtraInformation {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+		return strings.Join(lines, " ")
+	},
+	"category": func(h *n43.Header, m *n43.Movement, _ bool) string {
+		return m.Category
+	},
+	"tags": func(h *n43.Header, m *n43.Movement, _ bool) string {
+		return strings.Join(m.Tags, " ")
+	},
+}
+
+func formatCSVAmount(amount float64, decimalComma bool) string {
+	value := strconv.FormatFloat(amount, 'f', 2, 64)
+	if decimalComma {
+		value = strings.Replace(value, ".", ",", 1)
+	}
+	return value
+}
+
+func printCSV(w io.Writer, res n43.Norma43, columns string, delimiter string, decimalComma bool) error {
+	names := strings.Split(columns, ",")
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+		if _, ok := csvColumns[names[i]]; !ok {
+			return errors.New(names[i] + " is an unknown csv column")
+		}
+	}
+
+	comma, size := utf8.DecodeRuneInString(delimiter)
+	if size == 0 || size != len(delimiter) {
+		return errors.New("the csv delimiter must be a single character")
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	if err := cw.Write(names); err != nil {
+		return err
+	}
+
+	record := make([]string, len(names))
+	for _, account := range res.Accounts {
+		h := account.Header
+		if h == nil {
+			h = new(n43.Header)
+		}
+
+		for _, movement := range account.Movements {
+			for i, name := range names {
+				record[i] = csvColumns[name](h, movement, decimalComma)
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_printCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printCSV(&buf, parseSample(t), defaultCSVCols, ",", false); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 rows, but %d lines found", len(lines))
+	}
+	if lines[0] != defaultCSVCols {
+		t.Errorf("Expected the header %s, but %s found", defaultCSVCols, lines[0])
+	}
+}
+
+func Test_printCSVColumns(t *testing.T) {
+	tests := []struct {
+		columns      string
+		delimiter    string
+		decimalComma bool
+		expected     string
+	}{
+		{"transaction_date, amount", ",", false, "transaction_date,amount\n2020-02-03,-23.99\n2020-02-03,138.57\n"},
+		{"account_number,amount,balance", ";", true, "account_number;amount;balance\n3333444412;-23,99;2439,44\n3333444412;138,57;2578,01\n"},
+		{"amount", ",", true, "amount\n\"-23,99\"\n\"138,57\"\n"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := printCSV(&buf, parseSample(t), test.columns, test.delimiter, test.decimalComma); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("Expected\n%s\nwith columns %s, but\n%s\nfound", test.expected, test.columns, buf.String())
+		}
+	}
+}
+
+func Test_printCSVErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printCSV(&buf, parseSample(t), "amount,unknown", ",", false); err == nil || err.Error() != "unknown is an unknown csv column" {
+		t.Errorf("Expected an unknown column error, but %v found", err)
+	}
+	if err := printCSV(&buf, parseSample(t), "amount", ";;", false); err == nil {
+		t.Errorf("Expected a delimiter error, but nothing found")
+	}
+}
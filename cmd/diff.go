@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Xumeiquer/n43"
+)
+
+// diffCommand implements the diff command, which reports what changed from
+// one Norma43 file to another and, like diff, exits with exitFailure when
+// they differ.
+func diffCommand(args []string) int {
+	fs := newFlagSet("diff", "old new")
+
+	ops := parserFlags(fs)
+	out := fs.String("o", "", "Write to file instead of the standard output.")
+	format := fs.String("format", "text", "Output format: text or json.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitError
+	}
+
+	docs, err := readDocuments(fs.Args(), "n43", ops)
+	if err != nil {
+		return fail(err)
+	}
+	d := n43.Diff(docs[0].Norma43, docs[1].Norma43)
+
+	w, closeOutput, err := createOutput(*out)
+	if err != nil {
+		return fail(err)
+	}
+
+	switch *format {
+	case "text":
+		err = printDiff(w, docs[0].name, docs[1].name, d)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(d)
+	default:
+		err = errors.New("unknown output format " + *format)
+	}
+	err = closeOutput(err)
+	if err != nil {
+		return fail(err)
+	}
+
+	if !d.Empty() {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func printDiff(w io.Writer, oldName string, newName string, d *n43.DocumentDiff) error {
+	if d.Empty() {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("--- " + oldName + "\n")
+	b.WriteString("+++ " + newName + "\n")
+
+	for _, ad := range d.Accounts {
+		switch ad.Type {
+		case n43.DIFF_REMOVED:
+			b.WriteString("- account " + ad.IBAN + "\n")
+			continue
+		case n43.DIFF_ADDED:
+			b.WriteString("+ account " + ad.IBAN + "\n")
+			continue
+		}
+
+		b.WriteString("~ account " + ad.IBAN + "\n")
+		for _, c := range ad.Header {
+			b.WriteString("    header " + fieldChange(c) + "\n")
+		}
+		for _, c := range ad.Footer {
+			b.WriteString("    footer " + fieldChange(c) + "\n")
+		}
+
+		for _, md := range ad.Movements {
+			switch md.Type {
+			case n43.DIFF_REMOVED:
+				b.WriteString("  - " + movementSummary(md.Old) + "\n")
+			case n43.DIFF_ADDED:
+				b.WriteString("  + " + movementSummary(md.New) + "\n")
+			case n43.DIFF_MODIFIED:
+				b.WriteString("  ~ " + movementSummary(md.Old) + "\n")
+				for _, c := range md.Changes {
+					b.WriteString("      " + fieldChange(c) + "\n")
+				}
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func fieldChange(c *n43.FieldChange) string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field, strconv.Quote(c.Old), strconv.Quote(c.New))
+}
+
+func movementSummary(m *n43.Movement) string {
+	return formatDate(m.TransactionDate) + " " + formatAmount(m.Amount) + " " + movementText(m)
+}
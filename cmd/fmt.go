@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/Xumeiquer/n43"
+)
+
+// fmtCommand implements the fmt command, which rewrites Norma43 files in
+// their canonical form. With -check it only lists the files that are not
+// canonical and exits with exitFailure when there is any.
+func fmtCommand(args []string) int {
+	fs := newFlagSet("fmt", "[files...]")
+
+	check := fs.Bool("check", false, "List the files that are not canonical instead of formatting them.")
+	write := fs.Bool("w", false, "Write the result to the files instead of the standard output.")
+	crlf := fs.Bool("crlf", false, "End the records with CR LF.")
+	skipEOF := fs.Bool("skipEOF", false, "Leave out the end of file record.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	files, err := inputFiles("", fs.Args())
+	if err != nil {
+		return fail(err)
+	}
+
+	fo := &n43.FormatOptions{CRLF: *crlf, SkipEndOfFile: *skipEOF}
+
+	code := exitOK
+	for _, file := range files {
+		name := inputName(file)
+
+		data, err := readFile(file)
+		if err != nil {
+			return fail(err)
+		}
+
+		res, err := n43.Format(data, fo)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, name+": "+err.Error())
+			code = exitFailure
+			continue
+		}
+
+		switch {
+		case *check:
+			if !bytes.Equal(data, res) {
+				fmt.Println(name)
+				code = exitFailure
+			}
+		case *write && file != "":
+			if bytes.Equal(data, res) {
+				continue
+			}
+			// Written through a temporary file, so a failed write leaves the
+			// original untouched.
+			w, closeOutput, err := createOutput(file)
+			if err != nil {
+				return fail(err)
+			}
+			_, err = w.Write(res)
+			if err = closeOutput(err); err != nil {
+				return fail(err)
+			}
+		default:
+			if _, err := os.Stdout.Write(res); err != nil {
+				return fail(err)
+			}
+		}
+	}
+
+	return code
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xumeiquer/n43"
+)
+
+// generateCommand implements the generate command, which writes a synthetic
+// Norma43 document for tests and demos.
+func generateCommand(args []string) int {
+	fs := newFlagSet("generate", "")
+
+	out := fs.String("o", "", "Write to file instead of the standard output.")
+	accounts := fs.Int("accounts", 1, "Number of accounts.")
+	movements := fs.Int("movements", 20, "Number of movements of each account.")
+	from := fs.String("from", "2020-01-01", "Start date of the statements, as YYYY-MM-DD.")
+	to := fs.String("to", "2020-01-31", "End date of the statements, as YYYY-MM-DD.")
+	concepts := fs.String("concepts", "", "Weights of the concept codes, as code:weight separated by commas, like 12:5,03:2.")
+	extra := fs.Int("extra", 2, "Maximum number of extra information records of a movement, up to 5.")
+	currencies := fs.String("currencies", "978", "Numeric currency codes given in turn to the accounts, separated by commas.")
+	seed := fs.Int64("seed", 0, "Seed of the generator, the same seed gives the same document. Random by default.")
+	crlf := fs.Bool("crlf", false, "End the records with CR LF.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	// Zero stands for the default in the options, not in the flags.
+	if *accounts < 1 || *movements < 1 {
+		return fail(errors.New("the number of accounts and movements must be greater than 0"))
+	}
+
+	gop := &n43.GeneratorOptions{
+		Seed:       time.Now().UnixNano(),
+		Accounts:   *accounts,
+		Movements:  *movements,
+		Currencies: strings.Split(*currencies, ","),
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			gop.Seed = *seed
+		}
+	})
+
+	gop.ExtraInformation = *extra
+	if *extra == 0 {
+		gop.ExtraInformation = -1
+	}
+
+	var err error
+	if gop.StartDate, err = time.Parse("2006-01-02", *from); err != nil {
+		return fail(errors.New("wrong start date " + *from))
+	}
+	if gop.EndDate, err = time.Parse("2006-01-02", *to); err != nil {
+		return fail(errors.New("wrong end date " + *to))
+	}
+	if *concepts != "" {
+		if gop.Concepts, err = conceptWeights(*concepts); err != nil {
+			return fail(err)
+		}
+	}
+
+	res, err := n43.Generate(gop)
+	if err != nil {
+		return fail(err)
+	}
+
+	w, closeOutput, err := createOutput(*out)
+	if err != nil {
+		return fail(err)
+	}
+
+	err = n43.NewWriter(w, &n43.WriterOptions{CRLF: *crlf}).Write(res)
+	err = closeOutput(err)
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+// conceptWeights parses a list of code:weight pairs separated by commas.
+func conceptWeights(s string) (map[string]int, error) {
+	weights := map[string]int{}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 {
+			return nil, errors.New("wrong concept weight " + pair)
+		}
+		weight, err := strconv.Atoi(parts[1])
+		if err != nil || weight < 0 {
+			return nil, errors.New("wrong concept weight " + pair)
+		}
+		weights[parts[0]] = weight
+	}
+	return weights, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/Xumeiquer/n43"
+)
+
+// jsonDate is the layout of the dates written by the n43 package.
+const jsonDate = "2006-01-02"
+
+// movement is n43.Movement without its JSON methods, so its fields can be
+// embedded next to the account ones.
+type movement n43.Movement
+
+// movementLine is a movement with the identifiers of its account, as written
+// by the jsonl output format.
+type movementLine struct {
+	BankCode          string `json:"bank_code"`
+	AccountBranchCode string `json:"account_branch_code"`
+	AccountNumber     string `json:"account_number"`
+	AccountName       string `json:"account_name"`
+	AccountCurrency   string `json:"account_currency"`
+	*movement
+	TransactionDate string `json:"transaction_date"`
+	ValueDate       string `json:"value_date"`
+}
+
+func printJSON(w io.Writer, res n43.Norma43) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+func printJSONLines(w io.Writer, res n43.Norma43) error {
+	enc := json.NewEncoder(w)
+	for _, account := range res.Accounts {
+		for _, m := range account.Movements {
+			line := movementLine{
+				movement:        (*movement)(m),
+				TransactionDate: m.TransactionDate.Format(jsonDate),
+				ValueDate:       m.ValueDate.Format(jsonDate),
+			}
+			if account.Header != nil {
+				line.BankCode = account.Header.BankCode
+				line.AccountBranchCode = account.Header.BranchCode
+				line.AccountNumber = account.Header.AccountNumber
+				line.AccountName = account.Header.AccountName
+				line.AccountCurrency = account.Header.Currency
+			}
+
+			if err := enc.Encode(line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
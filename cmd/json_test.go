@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_printJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printJSON(&buf, parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{`"start_date": "2020-02-03"`, `"end_date": "2020-02-10"`, `"transaction_date": "2020-02-03"`, `"value_date": "2020-02-06"`} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %s in the output, but it is not found:\n%s", s, out)
+		}
+	}
+}
+
+func Test_printJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printJSONLines(&buf, parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, but %d found", len(lines))
+	}
+
+	var line map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &line); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"bank_code":        "1111",
+		"account_number":   "3333444412",
+		"account_currency": "978",
+		"transaction_date": "2020-02-03",
+		"value_date":       "2020-02-04",
+		"amount":           -23.99,
+	}
+	for k, v := range expected {
+		if line[k] != v {
+			t.Errorf("Expected %s to be %v, but %v found", k, v, line[k])
+		}
+	}
+}
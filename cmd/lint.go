@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Xumeiquer/n43"
+)
+
+// lintIssue is an issue with the name of its file, as written by the json
+// output.
+type lintIssue struct {
+	File string `json:"file"`
+	*n43.LintIssue
+}
+
+// lintCommand implements the lint command, which reports suspicious contents
+// of every input document. It exits with exitFailure when any issue is at
+// least as severe as -failOn or a document does not parse.
+func lintCommand(args []string) int {
+	fs := newFlagSet("lint", "[files...]")
+
+	ops := parserFlags(fs)
+	in := fs.String("in", "", "Read from file.")
+	out := fs.String("o", "", "Write the report to file instead of the standard output.")
+	format := fs.String("format", "text", "Output format: text or json.")
+	config := fs.String("config", "", "JSON file enabling or disabling rules and setting their severities.")
+	failOn := fs.String("failOn", "error", "Lowest severity that makes the command fail: error, warning or info.")
+	rules := fs.Bool("rules", false, "List the rules and exit.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	if *rules {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, rule := range n43.LintRules() {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", rule.ID, rule.Severity, rule.Description)
+		}
+		if err := tw.Flush(); err != nil {
+			return fail(err)
+		}
+		return exitOK
+	}
+
+	severities := map[string]int{string(n43.LINT_INFO): 0, string(n43.LINT_WARNING): 1, string(n43.LINT_ERROR): 2}
+	threshold, ok := severities[*failOn]
+	if !ok {
+		return fail(errors.New("unknown severity " + *failOn))
+	}
+	if *format != "text" && *format != "json" {
+		return fail(errors.New("unknown output format " + *format))
+	}
+
+	var lo *n43.LintOptions
+	if *config != "" {
+		f, err := os.Open(*config)
+		if err != nil {
+			return fail(err)
+		}
+		lo, err = n43.LoadLintOptions(f)
+		f.Close()
+		if err != nil {
+			return fail(errors.New(*config + ": " + err.Error()))
+		}
+	}
+
+	files, err := inputFiles(*in, fs.Args())
+	if err != nil {
+		return fail(err)
+	}
+
+	w, closeOutput, err := createOutput(*out)
+	if err != nil {
+		return fail(err)
+	}
+
+	code := exitOK
+	found := []*lintIssue{}
+	for _, file := range files {
+		name := inputName(file)
+
+		data, err := readFile(file)
+		if err != nil {
+			return fail(closeOutput(err))
+		}
+
+		res, err := parseDocument(data, "n43", ops)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, name+": "+err.Error())
+			code = exitFailure
+			continue
+		}
+
+		for _, issue := range res.Lint(lo) {
+			if severities[string(issue.Severity)] >= threshold {
+				code = exitFailure
+			}
+			found = append(found, &lintIssue{File: name, LintIssue: issue})
+		}
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(found)
+	} else {
+		for _, issue := range found {
+			if _, err = fmt.Fprintln(w, issue.File+": "+issue.Error()); err != nil {
+				break
+			}
+		}
+	}
+	err = closeOutput(err)
+	if err != nil {
+		return fail(err)
+	}
+	return code
+}
@@ -1,149 +1,339 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
-	"text/template"
 
 	"github.com/Xumeiquer/n43"
 )
 
-var (
-	trim            bool           = false
-	timeFormat      n43.TimeFormat = n43.ENGLISH_DATE
-	filterPositives bool           = false
-	filterNegatives bool           = false
-	filterLineIn    string         = ""
-	filterLineOut   string         = ""
-	headerTpl       string         = ".BankCode,.BranchCode,.AccountNumber,.StartDate,.EndDate,.InitialBalance,.Currency,.InformationModeCode,.AccountName"
-	lineTpl         string         = ".BranchCode,.TransactionDate,.ValueDate,.Amount,.Balance,.Description,.ExtraInformation"
-	footerTpl       string         = ".BankCode,.BranchCode,.AccountNumber,.DebitEntries,.DebitAmount,.CreditEntries,.CreditAmount,.FinalBalance,.Currency"
-	sepTpl          string         = " "
-	fin             string         = ""
-	versionFlag     bool           = false
+// Exit codes shared by every command.
+const (
+	exitOK = 0
+	// exitFailure means the command ran but found problems, like invalid
+	// documents.
+	exitFailure = 1
+	// exitError means the command could not run: wrong flags, unreadable
+	// inputs or unwritable outputs.
+	exitError = 2
+)
 
+var (
 	version string = ""
 	commit  string = ""
 	date    string = ""
 )
 
-func init() {
-	flag.BoolVar(&trim, "trim", trim, "Trim spaces surronding lines.")
-	flag.Var(&timeFormat, "timeFormat", "Time parse format.")
-	flag.BoolVar(&filterPositives, "filterPositive", filterPositives, "Filter positive values.")
-	flag.BoolVar(&filterNegatives, "filterNegative", filterNegatives, "Filter negative values.")
-	flag.StringVar(&filterLineIn, "filterLineIn", filterLineIn, "Filter (include) lines with extra information. This values will be used as regex.")
-	flag.StringVar(&filterLineOut, "filterLineOut", filterLineOut, "Filter (exclude) lines with extra information. This values will be used as regex.")
-	flag.StringVar(&headerTpl, "headerTpl", headerTpl, "Output template for the account header")
-	flag.StringVar(&footerTpl, "footerTpl", footerTpl, "Output template for the account footer")
-	flag.StringVar(&lineTpl, "lineTpl", lineTpl, "Output template for the movement line")
-	flag.StringVar(&sepTpl, "sepTpl", sepTpl, "Sparator character")
-	flag.StringVar(&fin, "in", fin, "Read from file.")
-	flag.BoolVar(&versionFlag, "version", versionFlag, "Show version")
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
 
-	flag.Parse()
+var commands = []*command{
+	{"parse", "Parse Norma43 files and print them. This is the default command.", parseCommand},
+	{"validate", "Check that footers and record counts match the movements.", validateCommand},
+	{"convert", "Convert Norma43, camt.053 and MT940 files to other formats.", convertCommand},
+	{"stats", "Print balances, totals and the largest movements of each account.", statsCommand},
+	{"lint", "Warn about suspicious dates, concepts, duplicates and texts.", lintCommand},
+	{"continuity", "Check that the statements of each account have no gaps or balance jumps.", continuityCommand},
+	{"recurring", "Find recurring payments and incomes, their next due date and missed ones.", recurringCommand},
+	{"diff", "Show the accounts and movements that changed between two files.", diffCommand},
+	{"merge", "Merge the statements of each account, dropping duplicated movements.", mergeCommand},
+	{"split", "Write every account as a Norma43 file of its own.", splitCommand},
+	{"fmt", "Rewrite Norma43 files in their canonical form.", fmtCommand},
+	{"anonymize", "Replace account numbers, names and texts with pseudonyms.", anonymizeCommand},
+	{"generate", "Write a synthetic Norma43 file with random accounts and movements.", generateCommand},
 }
 
 func main() {
-	if versionFlag {
-		showVerion()
-		return
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-h", "-help", "--help":
+			usage(os.Stdout)
+			return exitOK
+		case "version", "-version", "--version":
+			showVerion()
+			return exitOK
+		}
+
+		for _, c := range commands {
+			if c.name == args[0] {
+				return c.run(args[1:])
+			}
+		}
 	}
 
-	ops := &n43.ParserOptions{
-		Trim:           trim,
-		TimeFormat:     timeFormat,
-		FilterPositive: filterPositives,
-		FilterNegative: filterNegatives,
-		FilterLineIn:   filterLineIn,
-		FilterLineOut:  filterLineOut,
+	return parseCommand(args)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: n43 [command] [flags] [files...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-12s %s\n", c.name, c.summary)
 	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Files may be glob patterns. Without files the standard input is read.")
+	fmt.Fprintln(w, "Run n43 <command> -h for the flags of each command.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Exit codes: 0 success, 1 problems found, 2 error.")
+}
 
-	if fin == "" {
-		// read from stdin
-		stat, _ := os.Stdin.Stat()
-		if (stat.Mode() & os.ModeNamedPipe) != 0 {
-			stdin, err := io.ReadAll(os.Stdin)
-			if err != nil {
-				log.Fatal(err.Error())
-			}
+// newFlagSet returns the flag set of a command. Its usage message shows the
+// command arguments followed by the flags.
+func newFlagSet(name string, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: n43 %s [flags] %s\n\nFlags:\n", name, arguments)
+		fs.PrintDefaults()
+	}
+	return fs
+}
 
-			dataLines := strings.Split(string(stdin), "\n")
-			parser := n43.NewParser(dataLines, ops)
-			res, err := parser.Parse()
-			if err != nil {
-				log.Fatal(err.Error())
-			}
+// parseFlags parses the command arguments. When they are wrong or help was
+// requested it returns false and the exit code to use.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	err := fs.Parse(args)
+	if err == flag.ErrHelp {
+		return exitOK, false
+	}
+	if err != nil {
+		return exitError, false
+	}
+	return exitOK, true
+}
+
+// parserFlags adds the flags of the Norma43 parser to fs.
+func parserFlags(fs *flag.FlagSet) *n43.ParserOptions {
+	ops := &n43.ParserOptions{TimeFormat: n43.ENGLISH_DATE}
+
+	fs.BoolVar(&ops.Trim, "trim", false, "Trim spaces surronding lines.")
+	fs.Var(&ops.TimeFormat, "timeFormat", "Time parse format.")
+	fs.BoolVar(&ops.FilterPositive, "filterPositive", false, "Filter positive values.")
+	fs.BoolVar(&ops.FilterNegative, "filterNegative", false, "Filter negative values.")
+	fs.Func("filterLineIn", "Filter (include) lines with extra information. This values will be used as `regex`.", regexpFlag(&ops.FilterLineIn))
+	fs.Func("filterLineOut", "Filter (exclude) lines with extra information. This values will be used as `regex`.", regexpFlag(&ops.FilterLineOut))
+
+	return ops
+}
 
-			printOutput(*res)
+// regexpFlag returns the function setting a regular expression flag, failing
+// as wrong flags when it does not compile.
+func regexpFlag(p *string) func(string) error {
+	return func(s string) error {
+		if _, err := regexp.Compile(s); err != nil {
+			return err
 		}
-	} else {
-		data, err := os.ReadFile(fin)
-		if err != nil {
-			log.Fatal(err.Error())
+		*p = s
+		return nil
+	}
+}
+
+// categoriesFlag adds the flag of the categorization rules to fs.
+func categoriesFlag(fs *flag.FlagSet) *string {
+	return fs.String("categories", "", "JSON or YAML file with the rules setting the category and tags of the movements.")
+}
+
+// categorize sets the category and tags of the movements of every document
+// following the rules of the file, if any.
+func categorize(docs []*document, file string) error {
+	if file == "" {
+		return nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	c, err := n43.LoadCategorizer(f)
+	f.Close()
+	if err != nil {
+		return errors.New(file + ": " + err.Error())
+	}
+
+	for _, doc := range docs {
+		c.Categorize(doc.Norma43)
+	}
+	return nil
+}
+
+// document is a parsed input file.
+type document struct {
+	name string
+	*n43.Norma43
+}
+
+// inputFiles returns the file in, if any, followed by the arguments, with
+// the glob patterns expanded. An empty name or - stands for the standard
+// input, which is also read when there are no files at all.
+func inputFiles(in string, args []string) ([]string, error) {
+	patterns := args
+	if in != "" {
+		patterns = append([]string{in}, args...)
+	}
+	if len(patterns) == 0 {
+		return []string{""}, nil
+	}
+
+	files := []string{}
+	for _, pattern := range patterns {
+		if pattern == "-" {
+			files = append(files, "")
+			continue
 		}
 
-		dataLines := strings.Split(string(data), "\n")
-		parser := n43.NewParser(dataLines, ops)
-		res, err := parser.Parse()
+		matches, err := filepath.Glob(pattern)
 		if err != nil {
-			log.Fatal(err.Error())
+			return nil, err
 		}
+		if matches == nil {
+			// Let the missing file fail when it is read.
+			matches = []string{pattern}
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
 
-		printOutput(*res)
+// readInput reads the file fin, or the standard input when fin is empty and
+// it is not a terminal, like a pipe or a redirected file. It returns nil data
+// when there is nothing to read.
+func readInput(fin string) ([]byte, error) {
+	if fin == "" {
+		stat, err := os.Stdin.Stat()
+		if err != nil || stat.Mode()&os.ModeCharDevice != 0 {
+			return nil, nil
+		}
+		return io.ReadAll(os.Stdin)
 	}
+	return os.ReadFile(fin)
 }
 
-func printOutput(res n43.Norma43) {
-	tplData := TemplateData{
-		Documents: []n43.Norma43{res},
+// readDocuments reads and parses every file, see inputFiles. The format is
+// n43, camt053 or mt940.
+func readDocuments(files []string, format string, ops *n43.ParserOptions) ([]*document, error) {
+	docs := []*document{}
+
+	for _, file := range files {
+		data, err := readFile(file)
+		if err != nil {
+			return docs, err
+		}
+
+		res, err := parseDocument(data, format, ops)
+		if err != nil {
+			return docs, errors.New(inputName(file) + ": " + err.Error())
+		}
+		docs = append(docs, &document{name: inputName(file), Norma43: res})
 	}
 
-	tplGenerated := generateTeplate(headerTpl, lineTpl, footerTpl, sepTpl)
+	return docs, nil
+}
 
-	tpl := template.Must(template.New("output").Parse(tplGenerated))
-	err := tpl.Execute(os.Stdout, tplData)
-	if err != nil {
-		log.Fatalf("unable to generate the template. %s", err.Error())
+// readFile is readInput failing when there is nothing to read.
+func readFile(file string) ([]byte, error) {
+	data, err := readInput(file)
+	if err == nil && data == nil {
+		err = errors.New("nothing to read, name a file or pipe one")
 	}
+	return data, err
 }
 
-type TemplateData struct {
-	Documents []n43.Norma43
+func inputName(file string) string {
+	if file == "" {
+		return "<stdin>"
+	}
+	return file
 }
 
-func prepareTempaltes(tpl string, prefix string, sep string) string {
-	if tpl == "" {
-		return ""
+func parseDocument(data []byte, format string, ops *n43.ParserOptions) (*n43.Norma43, error) {
+	var doc *n43.Norma43
+	var err error
+
+	switch format {
+	case "n43":
+		dataLines := strings.Split(string(data), "\n")
+		parser := n43.NewParser(dataLines, ops)
+		return parser.Parse()
+	case "camt053":
+		doc, err = n43.NewCAMT053Reader(bytes.NewReader(data)).Parse()
+	case "mt940":
+		doc, err = n43.NewMT940Reader(bytes.NewReader(data)).Parse()
+	default:
+		return nil, errors.New("unknown input format " + format)
 	}
-	tpls := strings.Split(tpl, ",")
-	for i, t := range tpls {
-		tpls[i] = "{{" + prefix + t + "}}"
+	if err != nil {
+		return doc, err
 	}
-	return strings.Join(tpls, sep)
+
+	// The camt.053 and MT940 readers know nothing about the parser filters.
+	return doc, doc.Filter(ops)
 }
 
-func generateTeplate(header string, line string, footer string, sep string) string {
-	tpl := "{{- range $idx, $doc := .Documents }}{{- range $jdx, $account := $doc.Accounts }}"
+// combine returns a document with the accounts of every document.
+func combine(docs []*document) *n43.Norma43 {
+	if len(docs) == 1 {
+		return docs[0].Norma43
+	}
 
-	if header != "" {
-		tpl += "\n" + prepareTempaltes(header, ".Header", sep)
+	res := new(n43.Norma43)
+	for _, doc := range docs {
+		res.Accounts = append(res.Accounts, doc.Accounts...)
 	}
+	return res
+}
 
-	if line != "" {
-		tpl += "{{- range $kdx, $movement := .Movements }}\n" + prepareTempaltes(line, "", sep) + "{{ end }}"
+// createOutput creates the file out, or returns the standard output when out
+// is empty. The file is written next to out with a temporary name and the
+// returned function, given the error of the command if any, renames it to out
+// on success or removes it. It returns the error given or the one closing or
+// renaming the file, so a failed command leaves any previous out untouched.
+func createOutput(out string) (io.Writer, func(err error) error, error) {
+	if out == "" {
+		return os.Stdout, func(err error) error { return err }, nil
 	}
 
-	if footer != "" {
-		tpl += "\n" + prepareTempaltes(footer, ".Footer", sep)
+	f, err := os.CreateTemp(filepath.Dir(out), "."+filepath.Base(out)+".*")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, nil, err
 	}
 
-	tpl += "{{ end }}{{ end }}\n"
-	return tpl
+	return f, func(err error) error {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(f.Name(), out)
+		}
+		if err != nil {
+			os.Remove(f.Name())
+		}
+		return err
+	}, nil
+}
+
+// fail reports err and returns the error exit code.
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, "n43: "+err.Error())
+	return exitError
 }
 
 func showVerion() {
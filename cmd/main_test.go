@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Xumeiquer/n43"
+)
+
+const sampleData = `111111222233334444122002032002102000000002463439783ACCOUNT NAME ************
+22    22222002032002041240810000000000239900000000000000000000001234567890123456
+2301COMPRA TARG 1234XXXXXXXX3456 SHOP TO BUY SEVERAL THINGS IN THERE.
+22    22222002032002061240820000000001385700000000000000000000001234567890123456
+2301CREDIT CARD 1234567890123456 1234 .SUPERMARKET WHATEVER NAME INC.
+3311112222333344441200001000000000023990000100000000013857200000000257801978
+88999999999999999999000006`
+
+func parseSample(t *testing.T) n43.Norma43 {
+	t.Helper()
+
+	out, err := n43.NewParser(strings.Split(sampleData, "\n"), &n43.ParserOptions{Trim: true, TimeFormat: n43.ENGLISH_DATE}).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *out
+}
+
+func Test_parseDocumentFilter(t *testing.T) {
+	doc := parseSample(t)
+
+	var buf bytes.Buffer
+	if err := n43.NewMT940Writer(&buf).Write(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := parseDocument(buf.Bytes(), "mt940", &n43.ParserOptions{FilterNegative: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movements := out.Accounts[0].Movements; len(movements) != 1 || movements[0].Amount != 138.57 {
+		t.Errorf("Expected only the credit movement of the MT940 document, but %d movements found", len(movements))
+	}
+}
+
+// runCommand runs the command line args with the file stdin, if any, as
+// standard input and returns its exit code and standard output.
+func runCommand(t *testing.T, stdin string, args ...string) (int, string) {
+	t.Helper()
+
+	out, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	in, err := os.Open(os.DevNull)
+	if stdin != "" {
+		in, err = os.Open(stdin)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	oldStdin, oldStdout, oldStderr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = in, out, out
+	defer func() {
+		os.Stdin, os.Stdout, os.Stderr = oldStdin, oldStdout, oldStderr
+	}()
+
+	code := run(args)
+
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, string(data)
+}
+
+func Test_run(t *testing.T) {
+	dir := t.TempDir()
+
+	sample := filepath.Join(dir, "sample.n43")
+	broken := filepath.Join(dir, "broken.n43")
+	if err := os.WriteFile(sample, []byte(sampleData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(broken, []byte(strings.Replace(sampleData, "00000000257801", "00000000257802", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	categories := filepath.Join(dir, "categories.yaml")
+	if err := os.WriteFile(categories, []byte("rules:\n  - category: Cards\n    concepts: [\"12\"]\n    tags: [card]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	long := filepath.Join(dir, "long.n43")
+	if err := os.WriteFile(long, []byte(strings.Replace(sampleData, "INC.", "INC. AND SOME MORE TEXT THAN FITS", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		stdin  string
+		args   []string
+		code   int
+		output string
+	}{
+		{"valid file", "", []string{"validate", sample}, exitOK, sample + ": ok"},
+		{"invalid file", "", []string{"validate", broken}, exitFailure, "final balance"},
+		{"missing file", "", []string{"validate", filepath.Join(dir, "missing.n43")}, exitError, "no such file"},
+		{"unknown flag", "", []string{"validate", "-unknown", sample}, exitError, "flag provided but not defined"},
+		{"invalid filter", "", []string{"parse", "-filterLineIn", "(", sample}, exitError, "error parsing regexp"},
+		{"stdin", sample, []string{"parse", "-format", "csv", "-csvColumns", "amount"}, exitOK, "amount\n-23.99\n138.57\n"},
+		{"stdin with dash", sample, []string{"validate", "-"}, exitOK, "<stdin>: ok"},
+		{"yaml categories", "", []string{"parse", "-format", "csv", "-csvColumns", "category,tags", "-categories", categories, sample}, exitOK, "category,tags\nCards,card\nCards,card\n"},
+		{"terminal stdin", "", []string{"parse"}, exitError, "nothing to read"},
+		{"long record", "", []string{"fmt", "-check", long}, exitFailure, "records have 80"},
+		{"zero movements", "", []string{"generate", "-movements", "0"}, exitError, "must be greater than 0"},
+		{"wrong currency", "", []string{"generate", "-currencies", "978,EUR"}, exitError, "wrong currency EUR"},
+		{"zero scale", "", []string{"anonymize", "-scale", "0", sample}, exitError, "the scale must be greater than 0"},
+	}
+
+	for _, test := range tests {
+		code, output := runCommand(t, test.stdin, test.args...)
+		if code != test.code {
+			t.Errorf("Expected exit code %d with %s, but %d found: %s", test.code, test.name, code, output)
+		}
+		if !strings.Contains(output, test.output) {
+			t.Errorf("Expected %q in the output with %s, but %q found", test.output, test.name, output)
+		}
+	}
+}
+
+func Test_runOutput(t *testing.T) {
+	dir := t.TempDir()
+
+	sample := filepath.Join(dir, "sample.n43")
+	if err := os.WriteFile(sample, []byte(sampleData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.csv")
+
+	if code, output := runCommand(t, "", "parse", "-format", "csv", "-csvColumns", "amount", "-o", out, sample); code != exitOK || output != "" {
+		t.Fatalf("Expected the output to be written to the file, but exit code %d and %q found", code, output)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "amount\n-23.99\n138.57\n" {
+		t.Errorf("Expected the csv output in the file, but %q found", data)
+	}
+
+	if code, _ := runCommand(t, "", "parse", "-format", "csv", "-csvColumns", "unknown", "-o", out, sample); code != exitError {
+		t.Errorf("Expected exit code %d with an unknown column, but %d found", exitError, code)
+	}
+	if after, err := os.ReadFile(out); err != nil || !bytes.Equal(after, data) {
+		t.Errorf("Expected a failed command to leave the output file untouched, but %q found", after)
+	}
+
+	// fmt -w also writes through a temporary file.
+	crlf := filepath.Join(dir, "crlf.n43")
+	if err := os.WriteFile(crlf, []byte(strings.ReplaceAll(sampleData, "\n", "\r\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code, output := runCommand(t, "", "fmt", "-w", crlf); code != exitOK || output != "" {
+		t.Fatalf("Expected the file to be formatted in place, but exit code %d and %q found", code, output)
+	}
+	if code, output := runCommand(t, "", "fmt", "-check", crlf); code != exitOK {
+		t.Errorf("Expected a canonical file after fmt -w, but exit code %d and %q found", code, output)
+	}
+
+	if files, _ := os.ReadDir(dir); len(files) != 3 {
+		t.Errorf("Expected no temporary files left behind, but %d files found", len(files))
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Xumeiquer/n43"
+)
+
+// mergeCommand implements the merge command, which writes the statements of
+// the input documents as one Norma43 file with an account per IBAN and no
+// duplicated movements. Gaps and balance jumps between the statements are
+// reported as warnings, as the merged balances may not match the bank ones.
+func mergeCommand(args []string) int {
+	fs := newFlagSet("merge", "files...")
+
+	ops := parserFlags(fs)
+	out := fs.String("o", "", "Write to file instead of the standard output.")
+	crlf := fs.Bool("crlf", false, "End the records with CR LF.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	files, err := inputFiles("", fs.Args())
+	if err != nil {
+		return fail(err)
+	}
+	docs, err := readDocuments(files, "n43", ops)
+	if err != nil {
+		return fail(err)
+	}
+
+	res := []*n43.Norma43{}
+	for _, doc := range docs {
+		res = append(res, doc.Norma43)
+	}
+
+	for _, issue := range n43.CheckContinuity(res, nil) {
+		if issue.Type != n43.CONTINUITY_OVERLAP {
+			fmt.Fprintln(os.Stderr, "n43: warning: "+issue.Error())
+		}
+	}
+
+	w, closeOutput, err := createOutput(*out)
+	if err != nil {
+		return fail(err)
+	}
+
+	err = n43.NewWriter(w, &n43.WriterOptions{CRLF: *crlf}).Write(n43.Merge(res))
+	err = closeOutput(err)
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/Xumeiquer/n43"
+)
+
+const (
+	defaultHeaderTpl = ".BankCode,.BranchCode,.AccountNumber,.StartDate,.EndDate,.InitialBalance,.Currency,.InformationModeCode,.AccountName"
+	defaultLineTpl   = ".BranchCode,.TransactionDate,.ValueDate,.Amount,.Balance,.Description,.ExtraInformation"
+	defaultFooterTpl = ".BankCode,.BranchCode,.AccountNumber,.DebitEntries,.DebitAmount,.CreditEntries,.CreditAmount,.FinalBalance,.Currency"
+	defaultCSVCols   = "bank_code,account_branch_code,account_number,currency,branch_code,transaction_date,value_date,amount,balance,description,extra_information"
+)
+
+type parseOptions struct {
+	format       string
+	headerTpl    string
+	lineTpl      string
+	footerTpl    string
+	sepTpl       string
+	templateFile string
+	csvCols      string
+	csvDelimiter string
+	decimalComma bool
+}
+
+// parseCommand implements the parse command, which prints the input
+// documents in one of the output formats.
+func parseCommand(args []string) int {
+	fs := newFlagSet("parse", "[files...]")
+
+	ops := parserFlags(fs)
+	po := new(parseOptions)
+	categories := categoriesFlag(fs)
+
+	in := fs.String("in", "", "Read from file.")
+	out := fs.String("o", "", "Write to file instead of the standard output.")
+	fs.StringVar(&po.headerTpl, "headerTpl", defaultHeaderTpl, "Output template for the account header")
+	fs.StringVar(&po.footerTpl, "footerTpl", defaultFooterTpl, "Output template for the account footer")
+	fs.StringVar(&po.lineTpl, "lineTpl", defaultLineTpl, "Output template for the movement line")
+	fs.StringVar(&po.sepTpl, "sepTpl", " ", "Sparator character")
+	fs.StringVar(&po.templateFile, "template", "", "Template file for the text output, instead of the header, line and footer templates.")
+	fs.StringVar(&po.format, "format", "text", "Output format: text, json, jsonl, csv or html. The json output has one document per file.")
+	fs.StringVar(&po.csvCols, "csvColumns", defaultCSVCols, "Comma separated list of columns for the csv output.")
+	fs.StringVar(&po.csvDelimiter, "csvDelimiter", ",", "Field delimiter for the csv output.")
+	fs.BoolVar(&po.decimalComma, "decimalComma", false, "Use a decimal comma for amounts in the csv output.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	files, err := inputFiles(*in, fs.Args())
+	if err != nil {
+		return fail(err)
+	}
+	docs, err := readDocuments(files, "n43", ops)
+	if err != nil {
+		return fail(err)
+	}
+	if err := categorize(docs, *categories); err != nil {
+		return fail(err)
+	}
+
+	w, closeOutput, err := createOutput(*out)
+	if err != nil {
+		return fail(err)
+	}
+
+	err = printOutput(w, docs, po)
+	err = closeOutput(err)
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+func printOutput(w io.Writer, docs []*document, po *parseOptions) error {
+	switch po.format {
+	case "text":
+		return printTemplate(w, docs, po)
+	case "json":
+		for _, doc := range docs {
+			if err := printJSON(w, *doc.Norma43); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "jsonl":
+		for _, doc := range docs {
+			if err := printJSONLines(w, *doc.Norma43); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		return printCSV(w, *combine(docs), po.csvCols, po.csvDelimiter, po.decimalComma)
+	case "html":
+		return n43.NewHTMLWriter(w, nil).Write(combine(docs))
+	}
+	return errors.New("unknown output format " + po.format)
+}
+
+func printTemplate(w io.Writer, docs []*document, po *parseOptions) error {
+	tplData := TemplateData{}
+	for _, doc := range docs {
+		tplData.Documents = append(tplData.Documents, *doc.Norma43)
+	}
+
+	tplGenerated := generateTeplate(po.headerTpl, po.lineTpl, po.footerTpl, po.sepTpl)
+	if po.templateFile != "" {
+		data, err := os.ReadFile(po.templateFile)
+		if err != nil {
+			return err
+		}
+		tplGenerated = string(data)
+	}
+
+	tpl, err := template.New("output").Funcs(n43.TemplateFuncs()).Parse(tplGenerated)
+	if err != nil {
+		return errors.New("unable to parse the template. " + err.Error())
+	}
+	return tpl.Execute(w, tplData)
+}
+
+type TemplateData struct {
+	Documents []n43.Norma43
+}
+
+func prepareTempaltes(tpl string, prefix string, sep string) string {
+	if tpl == "" {
+		return ""
+	}
+	tpls := strings.Split(tpl, ",")
+	for i, t := range tpls {
+		tpls[i] = "{{" + prefix + t + "}}"
+	}
+	return strings.Join(tpls, sep)
+}
+
+func generateTeplate(header string, line string, footer string, sep string) string {
+	tpl := "{{- range $idx, $doc := .Documents }}{{- range $jdx, $account := $doc.Accounts }}"
+
+	if header != "" {
+		tpl += "\n" + prepareTempaltes(header, ".Header", sep)
+	}
+
+	if line != "" {
+		tpl += "{{- range $kdx, $movement := .Movements }}\n" + prepareTempaltes(line, "", sep) + "{{ end }}"
+	}
+
+	if footer != "" {
+		tpl += "\n" + prepareTempaltes(footer, ".Footer", sep)
+	}
+
+	tpl += "{{ end }}{{ end }}\n"
+	return tpl
+}
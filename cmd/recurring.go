@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/Xumeiquer/n43"
+)
+
+// recurringCommand implements the recurring command, which lists the
+// recurring payments and incomes found in the input documents, with their
+// next occurrence and the missed ones.
+func recurringCommand(args []string) int {
+	fs := newFlagSet("recurring", "[files...]")
+
+	ops := parserFlags(fs)
+	in := fs.String("in", "", "Read from file.")
+	out := fs.String("o", "", "Write to file instead of the standard output.")
+	format := fs.String("format", "text", "Output format: text or json.")
+	tolerance := fs.Float64("tolerance", 0.1, "Relative difference allowed between the amounts of consecutive occurrences.")
+	occurrences := fs.Int("occurrences", 3, "Number of movements needed to detect a recurring payment.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	files, err := inputFiles(*in, fs.Args())
+	if err != nil {
+		return fail(err)
+	}
+	docs, err := readDocuments(files, "n43", ops)
+	if err != nil {
+		return fail(err)
+	}
+
+	res := []*n43.Norma43{}
+	for _, doc := range docs {
+		res = append(res, doc.Norma43)
+	}
+	payments := n43.DetectRecurring(res, &n43.RecurringOptions{AmountTolerance: *tolerance, MinOccurrences: *occurrences})
+
+	w, closeOutput, err := createOutput(*out)
+	if err != nil {
+		return fail(err)
+	}
+
+	switch *format {
+	case "text":
+		err = printRecurring(w, payments)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(payments)
+	default:
+		err = errors.New("unknown output format " + *format)
+	}
+	err = closeOutput(err)
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+func printRecurring(w io.Writer, payments []*n43.RecurringPayment) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	iban := ""
+	for _, p := range payments {
+		if p.IBAN != iban {
+			if iban != "" {
+				fmt.Fprintln(tw)
+			}
+			iban = p.IBAN
+			fmt.Fprintln(tw, iban)
+		}
+
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%d occurrences, next %s %s\n", p.Counterparty, p.Periodicity, formatAmount(p.AverageAmount), len(p.Occurrences), formatDate(p.NextDate), formatAmount(p.NextAmount))
+		for _, c := range p.Changes {
+			fmt.Fprintf(tw, "    changed\t%s\t%s -> %s\t\n", formatDate(c.Movement.TransactionDate), formatAmount(c.PreviousAmount), formatAmount(c.Movement.Amount))
+		}
+		for _, date := range p.Missed {
+			fmt.Fprintf(tw, "    missed\t%s\t\t\n", formatDate(date))
+		}
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Xumeiquer/n43"
+)
+
+// splitName holds the fields available to the file name pattern of the
+// split command. Dates are formatted as 20060102.
+type splitName struct {
+	IBAN          string
+	BankCode      string
+	BranchCode    string
+	AccountNumber string
+	AccountName   string
+	Currency      string
+	StartDate     string
+	EndDate       string
+	// Index is the position of the account, starting at 1.
+	Index int
+}
+
+// splitCommand implements the split command, which writes every account of
+// the input documents as a Norma43 file of its own.
+func splitCommand(args []string) int {
+	fs := newFlagSet("split", "[files...]")
+
+	ops := parserFlags(fs)
+	in := fs.String("in", "", "Read from file.")
+	dir := fs.String("dir", ".", "Directory to write the files to.")
+	pattern := fs.String("pattern", "{{.BankCode}}_{{.AccountNumber}}_{{.StartDate}}.n43", "File name template. Fields: IBAN, BankCode, BranchCode, AccountNumber, AccountName, Currency, StartDate, EndDate and Index.")
+	crlf := fs.Bool("crlf", false, "End the records with CR LF.")
+	force := fs.Bool("force", false, "Overwrite existing files.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	tpl, err := template.New("name").Funcs(n43.TemplateFuncs()).Parse(*pattern)
+	if err != nil {
+		return fail(errors.New("unable to parse the pattern. " + err.Error()))
+	}
+
+	files, err := inputFiles(*in, fs.Args())
+	if err != nil {
+		return fail(err)
+	}
+	docs, err := readDocuments(files, "n43", ops)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Name every file first, so that nothing is written when one of them
+	// already exists.
+	splits := combine(docs).Split()
+	paths := make([]string, len(splits))
+	used := map[string]int{}
+	for i, doc := range splits {
+		name, err := splitFileName(tpl, doc.Accounts[0], i+1)
+		if err != nil {
+			return fail(err)
+		}
+
+		// Statements of the same account may get the same name.
+		used[name]++
+		if count := used[name]; count > 1 {
+			ext := filepath.Ext(name)
+			name = strings.TrimSuffix(name, ext) + "-" + strconv.Itoa(count) + ext
+		}
+
+		paths[i] = filepath.Join(*dir, name)
+		if _, err := os.Lstat(paths[i]); err == nil && !*force {
+			return fail(errors.New(paths[i] + " already exists, use -force to overwrite it"))
+		}
+	}
+
+	for i, doc := range splits {
+		if err := writeSplit(paths[i], doc, *crlf, *force); err != nil {
+			return fail(err)
+		}
+		fmt.Println(paths[i])
+	}
+
+	return exitOK
+}
+
+func splitFileName(tpl *template.Template, account *n43.Account, index int) (string, error) {
+	data := splitName{Index: index}
+	if h := account.Header; h != nil {
+		data.IBAN = h.IBAN()
+		data.BankCode = h.BankCode
+		data.BranchCode = h.BranchCode
+		data.AccountNumber = h.AccountNumber
+		data.AccountName = strings.TrimSpace(h.AccountName)
+		data.Currency = n43.CurrencyCode(h.Currency)
+		data.StartDate = h.StartDate.Format("20060102")
+		data.EndDate = h.EndDate.Format("20060102")
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	// The fields must not create directories.
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(strings.TrimSpace(buf.String()))
+	if name == "" {
+		return "", errors.New("the pattern gives an empty file name")
+	}
+	return name, nil
+}
+
+// writeSplit writes doc to the file path, which must not exist unless force
+// is set.
+func writeSplit(path string, doc *n43.Norma43, crlf bool, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = n43.NewWriter(f, &n43.WriterOptions{CRLF: crlf}).Write(doc)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_splitCommand(t *testing.T) {
+	dir := t.TempDir()
+
+	sample := filepath.Join(dir, "sample.n43")
+	if err := os.WriteFile(sample, []byte(sampleData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "1111_3333444412_20200203.n43")
+
+	if code, output := runCommand(t, "", "split", "-dir", dir, sample); code != exitOK || output != out+"\n" {
+		t.Fatalf("Expected the account to be written to %s, but exit code %d and %q found", out, code, output)
+	}
+
+	if err := os.WriteFile(out, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code, output := runCommand(t, "", "split", "-dir", dir, sample); code != exitError || !strings.Contains(output, "already exists") {
+		t.Errorf("Expected an existing file error, but exit code %d and %q found", code, output)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "previous" {
+		t.Errorf("Expected the existing file to be left untouched, but %q found", data)
+	}
+
+	if code, _ := runCommand(t, "", "split", "-force", "-dir", dir, sample); code != exitOK {
+		t.Errorf("Expected the existing file to be overwritten with -force, but exit code %d found", code)
+	}
+	if data, _ := os.ReadFile(out); !strings.HasPrefix(string(data), "11111122223333444412") {
+		t.Errorf("Expected the account in the overwritten file, but %q found", data)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Xumeiquer/n43"
+)
+
+// statsCommand implements the stats command, which prints the statistics of
+// every account found in the input documents.
+func statsCommand(args []string) int {
+	fs := newFlagSet("stats", "[files...]")
+
+	ops := parserFlags(fs)
+	categories := categoriesFlag(fs)
+	in := fs.String("in", "", "Read from file.")
+	out := fs.String("o", "", "Write to file instead of the standard output.")
+	format := fs.String("format", "text", "Output format: text or json.")
+	largest := fs.Int("largest", 5, "Number of largest movements to show per account.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	files, err := inputFiles(*in, fs.Args())
+	if err != nil {
+		return fail(err)
+	}
+	docs, err := readDocuments(files, "n43", ops)
+	if err != nil {
+		return fail(err)
+	}
+	if err := categorize(docs, *categories); err != nil {
+		return fail(err)
+	}
+
+	res := []*n43.Norma43{}
+	for _, doc := range docs {
+		res = append(res, doc.Norma43)
+	}
+	summary := n43.Summarize(res, &n43.SummaryOptions{Largest: *largest})
+
+	w, closeOutput, err := createOutput(*out)
+	if err != nil {
+		return fail(err)
+	}
+
+	switch *format {
+	case "text":
+		err = printSummary(w, summary)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(summary)
+	default:
+		err = errors.New("unknown output format " + *format)
+	}
+	err = closeOutput(err)
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+func printSummary(w io.Writer, summary *n43.Summary) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	for _, as := range summary.Accounts {
+		fmt.Fprintf(tw, "%s %s (%s)\n", as.IBAN, as.AccountName, as.Currency)
+		fmt.Fprintf(tw, "  Period\t%s - %s\t%d statements\n", formatDate(as.StartDate), formatDate(as.EndDate), as.Statements)
+		fmt.Fprintf(tw, "  Opening balance\t%s\t\n", formatAmount(as.OpeningBalance))
+		fmt.Fprintf(tw, "  Closing balance\t%s\t\n", formatAmount(as.ClosingBalance))
+		printTotals(tw, as.Totals)
+
+		if len(as.Largest) > 0 {
+			fmt.Fprintln(tw, "  Largest movements")
+			for _, m := range as.Largest {
+				fmt.Fprintf(tw, "    %s\t%s\t%s\n", formatDate(m.TransactionDate), formatAmount(m.Amount), strings.TrimSpace(movementText(m)))
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+
+	if len(summary.Accounts) > 1 {
+		fmt.Fprintln(tw, "All accounts")
+		printTotals(tw, summary.Totals)
+	}
+
+	return tw.Flush()
+}
+
+func printTotals(w io.Writer, t *n43.Totals) {
+	fmt.Fprintf(w, "  Debits\t%s\t%d entries, average %s\n", formatAmount(-t.DebitAmount), t.DebitEntries, formatAmount(-t.AverageDebit))
+	fmt.Fprintf(w, "  Credits\t%s\t%d entries, average %s\n", formatAmount(t.CreditAmount), t.CreditEntries, formatAmount(t.AverageCredit))
+
+	fmt.Fprintln(w, "  By concept")
+	for _, g := range t.ByConcept {
+		fmt.Fprintf(w, "    %s %s\t%s\t%d entries\n", g.Key, n43.ConceptDescription(g.Key), formatAmount(g.Net), g.Entries)
+	}
+
+	fmt.Fprintln(w, "  By month")
+	for _, g := range t.ByMonth {
+		fmt.Fprintf(w, "    %s\t%s\t%d entries\n", g.Key, formatAmount(g.Net), g.Entries)
+	}
+
+	if len(t.ByCategory) > 0 {
+		fmt.Fprintln(w, "  By category")
+		for _, g := range t.ByCategory {
+			key := g.Key
+			if key == "" {
+				key = "(none)"
+			}
+			fmt.Fprintf(w, "    %s\t%s\t%d entries\n", key, formatAmount(g.Net), g.Entries)
+		}
+	}
+}
+
+func formatDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func formatAmount(value float64) string {
+	return formatCSVAmount(value, false)
+}
+
+// movementText returns the first complementary concept of m, or its
+// references when it has none.
+func movementText(m *n43.Movement) string {
+	if concepts := m.ComplementaryConcepts(); len(concepts) > 0 {
+		return strings.Join(strings.Fields(concepts[0]), " ")
+	}
+	return m.Description
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+)
+
+// validateCommand implements the validate command, which reports the
+// inconsistencies of every input document and exits with exitFailure when
+// any of them is invalid or does not parse.
+func validateCommand(args []string) int {
+	fs := newFlagSet("validate", "[files...]")
+
+	ops := parserFlags(fs)
+	in := fs.String("in", "", "Read from file.")
+	out := fs.String("o", "", "Write the report to file instead of the standard output.")
+	quiet := fs.Bool("q", false, "Only report the invalid documents.")
+
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	files, err := inputFiles(*in, fs.Args())
+	if err != nil {
+		return fail(err)
+	}
+
+	w, closeOutput, err := createOutput(*out)
+	if err != nil {
+		return fail(err)
+	}
+
+	code := exitOK
+	for _, file := range files {
+		name := inputName(file)
+
+		data, err := readFile(file)
+		if err != nil {
+			return fail(closeOutput(err))
+		}
+
+		res, err := parseDocument(data, "n43", ops)
+		if err != nil {
+			fmt.Fprintln(w, name+": "+err.Error())
+			code = exitFailure
+			continue
+		}
+
+		errs := res.Validate()
+		for _, e := range errs {
+			fmt.Fprintln(w, name+": "+e.Error())
+		}
+		if len(errs) > 0 {
+			code = exitFailure
+		} else if !*quiet {
+			fmt.Fprintln(w, name+": ok")
+		}
+	}
+
+	if err := closeOutput(nil); err != nil {
+		return fail(err)
+	}
+	return code
+}
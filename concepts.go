@@ -0,0 +1,102 @@
+package n43
+
+import "strings"
+
+// Common concept codes defined by the AEB for the movement records.
+const (
+	CONCEPT_CHECKS        = "01"
+	CONCEPT_DEPOSITS      = "02"
+	CONCEPT_DIRECT_DEBITS = "03"
+	CONCEPT_TRANSFERS     = "04"
+	CONCEPT_LOANS         = "05"
+	CONCEPT_REMITTANCES   = "06"
+	CONCEPT_SUBSCRIPTIONS = "07"
+	CONCEPT_DIVIDENDS     = "08"
+	CONCEPT_SECURITIES    = "09"
+	CONCEPT_FUEL_CHECKS   = "10"
+	CONCEPT_ATM           = "11"
+	CONCEPT_CARDS         = "12"
+	CONCEPT_FOREIGN       = "13"
+	CONCEPT_RETURNS       = "14"
+	CONCEPT_PAYROLL       = "15"
+	CONCEPT_STAMP_DUTIES  = "16"
+	CONCEPT_INTEREST_FEES = "17"
+	CONCEPT_CANCELLATIONS = "98"
+	CONCEPT_MISCELLANEOUS = "99"
+)
+
+var conceptDescriptions = map[string]string{
+	CONCEPT_CHECKS:        "Checks - withdrawals",
+	CONCEPT_DEPOSITS:      "Deposits - payments in",
+	CONCEPT_DIRECT_DEBITS: "Direct debits - bills - payments",
+	CONCEPT_TRANSFERS:     "Transfers - money orders - checks",
+	CONCEPT_LOANS:         "Loan and credit repayments",
+	CONCEPT_REMITTANCES:   "Bill remittances",
+	CONCEPT_SUBSCRIPTIONS: "Subscriptions - liability dividends - exchanges",
+	CONCEPT_DIVIDENDS:     "Dividends - coupons - premiums - redemptions",
+	CONCEPT_SECURITIES:    "Stock exchange and securities operations",
+	CONCEPT_FUEL_CHECKS:   "Fuel checks",
+	CONCEPT_ATM:           "Cash machine",
+	CONCEPT_CARDS:         "Credit and debit cards",
+	CONCEPT_FOREIGN:       "Foreign operations",
+	CONCEPT_RETURNS:       "Returns and unpaid items",
+	CONCEPT_PAYROLL:       "Payroll - social security",
+	CONCEPT_STAMP_DUTIES:  "Stamp duties - brokerage - policies",
+	CONCEPT_INTEREST_FEES: "Interest - fees - custody - expenses and taxes",
+	CONCEPT_CANCELLATIONS: "Cancellations - entry corrections",
+	CONCEPT_MISCELLANEOUS: "Miscellaneous",
+}
+
+// ConceptDescription returns the description of an AEB common concept code,
+// or an empty string when the code is unknown.
+func ConceptDescription(code string) string {
+	return conceptDescriptions[code]
+}
+
+// ComplementaryConcepts returns the non empty complementary concepts of the
+// extra information lines of the movement, without their trailing spaces.
+// Each record 23 carries two concepts of 38 characters.
+func (m *Movement) ComplementaryConcepts() []string {
+	concepts := []string{}
+	for _, line := range m.ExtraInformation {
+		for len(line) > 0 {
+			size := 38
+			if len(line) < size {
+				size = len(line)
+			}
+			if concept := strings.TrimRight(line[:size], " "); strings.TrimSpace(concept) != "" {
+				concepts = append(concepts, concept)
+			}
+			line = line[size:]
+		}
+	}
+	return concepts
+}
+
+// extraInformation packs free text into extra information lines of two
+// complementary concepts of 38 characters, the reverse of
+// ComplementaryConcepts.
+func extraInformation(texts []string) []string {
+	concepts := []string{}
+	for _, text := range texts {
+		r := []rune(strings.TrimRight(text, " "))
+		for len(r) > 0 {
+			size := 38
+			if len(r) < size {
+				size = len(r)
+			}
+			concepts = append(concepts, string(r[:size]))
+			r = r[size:]
+		}
+	}
+
+	lines := []string{}
+	for i := 0; i < len(concepts); i += 2 {
+		line := concepts[i]
+		if i+1 < len(concepts) {
+			line = field(line, 38) + concepts[i+1]
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,137 @@
+package n43
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type ContinuityIssueType string
+
+const (
+	CONTINUITY_GAP     ContinuityIssueType = "gap"
+	CONTINUITY_OVERLAP ContinuityIssueType = "overlap"
+	CONTINUITY_BALANCE ContinuityIssueType = "balance"
+)
+
+// ContinuityIssue is a problem between two consecutive statements of the
+// same account: days no statement covers, days covered by both, or an
+// initial balance different from the previous final balance.
+type ContinuityIssue struct {
+	Type ContinuityIssueType `json:"type"`
+	IBAN string              `json:"iban"`
+	// From and To are the first and last days of the gap or the overlap.
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Expected is the final balance of the previous statement and Found the
+	// initial balance of the next one.
+	Expected float64  `json:"expected"`
+	Found    float64  `json:"found"`
+	Previous *Account `json:"-"`
+	Next     *Account `json:"-"`
+}
+
+func (ci *ContinuityIssue) Error() string {
+	switch ci.Type {
+	case CONTINUITY_GAP:
+		return fmt.Sprintf("%s: no statement from %s to %s", ci.IBAN, ci.From.Format("2006-01-02"), ci.To.Format("2006-01-02"))
+	case CONTINUITY_OVERLAP:
+		return fmt.Sprintf("%s: statements overlap from %s to %s", ci.IBAN, ci.From.Format("2006-01-02"), ci.To.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("%s: initial balance %.2f on %s differs from the previous final balance %.2f", ci.IBAN, ci.Found, ci.From.Format("2006-01-02"), ci.Expected)
+}
+
+type ContinuityOptions struct {
+	// IgnoreWeekends does not report the gaps made only of Saturdays and
+	// Sundays, the days many banks send no statement.
+	IgnoreWeekends bool
+}
+
+// CheckContinuity orders the statements of each account found in the
+// documents by their StartDate and EndDate, and reports the gaps, overlaps
+// and balance discrepancies between consecutive statements. Accounts are
+// checked in order of appearance.
+func CheckContinuity(docs []*Norma43, continuityOptions *ContinuityOptions) []*ContinuityIssue {
+	co := new(ContinuityOptions)
+
+	if continuityOptions != nil {
+		co.IgnoreWeekends = continuityOptions.IgnoreWeekends
+	}
+
+	statements := map[string][]*Account{}
+	order := []string{}
+	for _, doc := range docs {
+		for _, account := range doc.Accounts {
+			if account.Header == nil {
+				continue
+			}
+			iban := account.Header.IBAN()
+			if _, ok := statements[iban]; !ok {
+				order = append(order, iban)
+			}
+			statements[iban] = append(statements[iban], account)
+		}
+	}
+
+	issues := []*ContinuityIssue{}
+	for _, iban := range order {
+		accounts := statements[iban]
+		sort.SliceStable(accounts, func(i, j int) bool {
+			hi, hj := accounts[i].Header, accounts[j].Header
+			if hi.StartDate.Equal(hj.StartDate) {
+				return hi.EndDate.Before(hj.EndDate)
+			}
+			return hi.StartDate.Before(hj.StartDate)
+		})
+
+		for i := 1; i < len(accounts); i++ {
+			issues = append(issues, checkStatements(iban, accounts[i-1], accounts[i], co)...)
+		}
+	}
+
+	return issues
+}
+
+func checkStatements(iban string, previous *Account, next *Account, co *ContinuityOptions) []*ContinuityIssue {
+	issues := []*ContinuityIssue{}
+	add := func(t ContinuityIssueType, from time.Time, to time.Time) *ContinuityIssue {
+		issue := &ContinuityIssue{Type: t, IBAN: iban, From: from, To: to, Previous: previous, Next: next}
+		issues = append(issues, issue)
+		return issue
+	}
+
+	ph, nh := previous.Header, next.Header
+	firstMissing := ph.EndDate.AddDate(0, 0, 1)
+	lastMissing := nh.StartDate.AddDate(0, 0, -1)
+
+	switch {
+	case !firstMissing.After(lastMissing):
+		if !co.IgnoreWeekends || !onlyWeekends(firstMissing, lastMissing) {
+			add(CONTINUITY_GAP, firstMissing, lastMissing)
+		}
+	case !nh.StartDate.After(ph.EndDate):
+		to := ph.EndDate
+		if nh.EndDate.Before(to) {
+			to = nh.EndDate
+		}
+		add(CONTINUITY_OVERLAP, nh.StartDate, to)
+	}
+
+	final := roundAmount(accountFinalBalance(previous))
+	if initial := roundAmount(nh.InitialBalance); initial != final {
+		issue := add(CONTINUITY_BALANCE, nh.StartDate, nh.StartDate)
+		issue.Expected = final
+		issue.Found = initial
+	}
+
+	return issues
+}
+
+func onlyWeekends(from time.Time, to time.Time) bool {
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package n43
+
+import (
+	"testing"
+	"time"
+)
+
+func continuityStatement(start string, end string, initial float64, final float64) *Norma43 {
+	startDate, _ := time.Parse("2006-01-02", start)
+	endDate, _ := time.Parse("2006-01-02", end)
+
+	return &Norma43{Accounts: []*Account{{
+		Header: &Header{BankCode: "1111", BranchCode: "2222", AccountNumber: "3333444412", StartDate: startDate, EndDate: endDate, InitialBalance: initial},
+		Footer: &Footer{FinalBalance: final},
+	}}}
+}
+
+func Test_continuity(t *testing.T) {
+	docs := []*Norma43{
+		continuityStatement("2020-02-05", "2020-02-05", 90, 80),
+		continuityStatement("2020-02-03", "2020-02-03", 100, 95),
+		continuityStatement("2020-02-04", "2020-02-04", 95, 90),
+		// Friday to Monday leaves the weekend out.
+		continuityStatement("2020-02-07", "2020-02-07", 80, 70),
+		continuityStatement("2020-02-10", "2020-02-12", 70, 60),
+		continuityStatement("2020-02-12", "2020-02-13", 61, 50),
+	}
+
+	issues := CheckContinuity(docs, nil)
+	expected := []string{
+		"ES4811112222033333444412: no statement from 2020-02-06 to 2020-02-06",
+		"ES4811112222033333444412: no statement from 2020-02-08 to 2020-02-09",
+		"ES4811112222033333444412: statements overlap from 2020-02-12 to 2020-02-12",
+		"ES4811112222033333444412: initial balance 61.00 on 2020-02-12 differs from the previous final balance 60.00",
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, but %v found", len(expected), issues)
+	}
+	for i, issue := range issues {
+		if issue.Error() != expected[i] {
+			t.Errorf("Expected %q, but %q found", expected[i], issue.Error())
+		}
+	}
+	if issues[3].Previous != docs[4].Accounts[0] || issues[3].Next != docs[5].Accounts[0] {
+		t.Errorf("Expected the issue to point to the statements")
+	}
+
+	issues = CheckContinuity(docs, &ContinuityOptions{IgnoreWeekends: true})
+	if len(issues) != 3 || issues[1].Type != CONTINUITY_OVERLAP {
+		t.Errorf("Expected the weekend gap to be ignored, but %v found", issues)
+	}
+}
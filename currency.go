@@ -0,0 +1,45 @@
+package n43
+
+// currencyCodes maps the ISO 4217 numeric codes used by the Norma43 records
+// to their alphabetic codes.
+var currencyCodes = map[string]string{
+	"032": "ARS",
+	"036": "AUD",
+	"124": "CAD",
+	"156": "CNY",
+	"203": "CZK",
+	"208": "DKK",
+	"348": "HUF",
+	"392": "JPY",
+	"484": "MXN",
+	"578": "NOK",
+	"752": "SEK",
+	"756": "CHF",
+	"826": "GBP",
+	"840": "USD",
+	"946": "RON",
+	"949": "TRY",
+	"978": "EUR",
+	"985": "PLN",
+	"986": "BRL",
+}
+
+// CurrencyCode returns the ISO 4217 alphabetic code of a numeric currency
+// code. Unknown codes are returned unchanged.
+func CurrencyCode(numeric string) string {
+	if code, ok := currencyCodes[numeric]; ok {
+		return code
+	}
+	return numeric
+}
+
+// CurrencyNumber returns the ISO 4217 numeric code of an alphabetic currency
+// code. Unknown codes are returned unchanged.
+func CurrencyNumber(code string) string {
+	for numeric, alpha := range currencyCodes {
+		if alpha == code {
+			return numeric
+		}
+	}
+	return code
+}
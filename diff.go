@@ -0,0 +1,239 @@
+package n43
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DiffType tells whether an account or a movement was added, removed or
+// modified.
+type DiffType string
+
+const (
+	DIFF_ADDED    DiffType = "added"
+	DIFF_REMOVED  DiffType = "removed"
+	DIFF_MODIFIED DiffType = "modified"
+)
+
+// DocumentDiff holds the differences between two Norma43 documents.
+type DocumentDiff struct {
+	Accounts []*AccountDiff `json:"accounts"`
+}
+
+// AccountDiff holds the differences of an account. Added and removed
+// accounts carry no field changes nor movements.
+type AccountDiff struct {
+	Type      DiffType        `json:"type"`
+	IBAN      string          `json:"iban"`
+	Header    []*FieldChange  `json:"header,omitempty"`
+	Footer    []*FieldChange  `json:"footer,omitempty"`
+	Movements []*MovementDiff `json:"movements,omitempty"`
+}
+
+// MovementDiff is a movement added, removed or modified. Movements are
+// matched by their fingerprints, see Fingerprints.
+type MovementDiff struct {
+	Type DiffType `json:"type"`
+	// Old is nil for added movements and New for removed ones.
+	Old     *Movement      `json:"old,omitempty"`
+	New     *Movement      `json:"new,omitempty"`
+	Changes []*FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is a field with a different value in each document. The field
+// is named after its json tag and the values are formatted as text.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type diffField struct {
+	name  string
+	value string
+}
+
+// Diff returns the differences from document a to document b. Accounts
+// are matched by IBAN, in order when an account has several statements.
+// Movements are matched by fingerprint first; the rest are paired when they
+// share the transaction date and most of their fields, and reported as
+// modified, or else as removed from a and added to b. Balances are not
+// compared as they follow from the amounts.
+func Diff(a *Norma43, b *Norma43) *DocumentDiff {
+	d := &DocumentDiff{Accounts: []*AccountDiff{}}
+
+	matched := make([]bool, len(b.Accounts))
+	for _, oldAccount := range a.Accounts {
+		iban := accountIBAN(oldAccount)
+
+		found := -1
+		for j, newAccount := range b.Accounts {
+			if !matched[j] && accountIBAN(newAccount) == iban {
+				found = j
+				break
+			}
+		}
+		if found < 0 {
+			d.Accounts = append(d.Accounts, &AccountDiff{Type: DIFF_REMOVED, IBAN: iban})
+			continue
+		}
+		matched[found] = true
+
+		if ad := compareAccounts(oldAccount, b.Accounts[found]); ad != nil {
+			d.Accounts = append(d.Accounts, ad)
+		}
+	}
+
+	for j, newAccount := range b.Accounts {
+		if !matched[j] {
+			d.Accounts = append(d.Accounts, &AccountDiff{Type: DIFF_ADDED, IBAN: accountIBAN(newAccount)})
+		}
+	}
+
+	return d
+}
+
+// Empty reports whether both documents have the same contents.
+func (d *DocumentDiff) Empty() bool {
+	return len(d.Accounts) == 0
+}
+
+func compareAccounts(a *Account, b *Account) *AccountDiff {
+	ad := &AccountDiff{
+		Type:   DIFF_MODIFIED,
+		IBAN:   accountIBAN(a),
+		Header: compareFields(headerFields(a.Header), headerFields(b.Header)),
+		Footer: compareFields(footerFields(a.Footer), footerFields(b.Footer)),
+	}
+
+	oldFingerprints := a.Fingerprints()
+	newFingerprints := b.Fingerprints()
+
+	newIndex := map[string]int{}
+	for j, fingerprint := range newFingerprints {
+		newIndex[fingerprint] = j
+	}
+
+	// Movements found in both documents.
+	matched := make([]bool, len(b.Movements))
+	removed := []*Movement{}
+	for i, fingerprint := range oldFingerprints {
+		if j, ok := newIndex[fingerprint]; ok {
+			matched[j] = true
+			continue
+		}
+		removed = append(removed, a.Movements[i])
+	}
+
+	for _, old := range removed {
+		best, bestScore := -1, 0
+		oldFields := movementFields(old)
+		for j, m := range b.Movements {
+			if matched[j] || !m.TransactionDate.Equal(old.TransactionDate) {
+				continue
+			}
+			if score := len(oldFields) - len(compareFields(oldFields, movementFields(m))); score > bestScore {
+				best, bestScore = j, score
+			}
+		}
+
+		// Paired movements must share more than half of their fields.
+		if best < 0 || bestScore*2 <= len(oldFields) {
+			ad.Movements = append(ad.Movements, &MovementDiff{Type: DIFF_REMOVED, Old: old})
+			continue
+		}
+
+		matched[best] = true
+		ad.Movements = append(ad.Movements, &MovementDiff{
+			Type:    DIFF_MODIFIED,
+			Old:     old,
+			New:     b.Movements[best],
+			Changes: compareFields(oldFields, movementFields(b.Movements[best])),
+		})
+	}
+
+	for j, m := range b.Movements {
+		if !matched[j] {
+			ad.Movements = append(ad.Movements, &MovementDiff{Type: DIFF_ADDED, New: m})
+		}
+	}
+
+	if len(ad.Header) == 0 && len(ad.Footer) == 0 && len(ad.Movements) == 0 {
+		return nil
+	}
+	return ad
+}
+
+func compareFields(a []diffField, b []diffField) []*FieldChange {
+	changes := []*FieldChange{}
+	for i := range a {
+		if a[i].value != b[i].value {
+			changes = append(changes, &FieldChange{Field: a[i].name, Old: a[i].value, New: b[i].value})
+		}
+	}
+	return changes
+}
+
+func headerFields(h *Header) []diffField {
+	if h == nil {
+		h = new(Header)
+	}
+	return []diffField{
+		{"bank_code", h.BankCode},
+		{"branch_code", h.BranchCode},
+		{"account_number", h.AccountNumber},
+		{"start_date", h.StartDate.Format("2006-01-02")},
+		{"end_date", h.EndDate.Format("2006-01-02")},
+		{"initial_balance", diffAmount(h.InitialBalance)},
+		{"currency", h.Currency},
+		{"information_mode_code", h.InformationModeCode},
+		{"account_name", strings.TrimSpace(h.AccountName)},
+	}
+}
+
+func footerFields(f *Footer) []diffField {
+	if f == nil {
+		f = new(Footer)
+	}
+	return []diffField{
+		{"bank_code", f.BankCode},
+		{"branch_code", f.BranchCode},
+		{"account_number", f.AccountNumber},
+		{"debit_entries", strconv.Itoa(f.DebitEntries)},
+		{"debit_amount", diffAmount(f.DebitAmount)},
+		{"credit_entries", strconv.Itoa(f.CreditEntries)},
+		{"credit_amount", diffAmount(f.CreditAmount)},
+		{"final_balance", diffAmount(f.FinalBalance)},
+		{"currency", f.Currency},
+	}
+}
+
+func movementFields(m *Movement) []diffField {
+	extra := make([]string, 0, len(m.ExtraInformation))
+	for _, line := range m.ExtraInformation {
+		extra = append(extra, strings.TrimSpace(line))
+	}
+
+	return []diffField{
+		{"branch_code", m.BranchCode},
+		{"transaction_date", m.TransactionDate.Format("2006-01-02")},
+		{"value_date", m.ValueDate.Format("2006-01-02")},
+		{"common_concept", m.CommonConcept},
+		{"own_concept", m.OwnConcept},
+		{"amount", diffAmount(m.Amount)},
+		{"document_number", strings.TrimSpace(m.DocumentNumber)},
+		{"description", strings.TrimSpace(m.Description)},
+		{"extra_information", strings.Join(extra, "\n")},
+	}
+}
+
+func diffAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func accountIBAN(a *Account) string {
+	if a.Header == nil {
+		return ""
+	}
+	return a.Header.IBAN()
+}
@@ -0,0 +1,60 @@
+package n43
+
+import (
+	"testing"
+)
+
+func Test_diff(t *testing.T) {
+	a := parseSample(t)
+
+	if d := Diff(a, parseSample(t)); !d.Empty() {
+		t.Errorf("Expected no differences, but %+v found", d.Accounts)
+	}
+
+	b := parseSample(t)
+	account := b.Accounts[0]
+	account.Header.AccountName = "RENAMED"
+	account.Footer.FinalBalance = 2000
+	// Corrected amount, removed and added movements.
+	account.Movements[0].Amount = -24.99
+	account.Movements = append(account.Movements[:4], &Movement{
+		TransactionDate: account.Header.EndDate,
+		ValueDate:       account.Header.EndDate,
+		CommonConcept:   CONCEPT_TRANSFERS,
+		OwnConcept:      "000",
+		Amount:          10,
+	})
+
+	d := Diff(a, b)
+	if len(d.Accounts) != 1 || d.Accounts[0].Type != DIFF_MODIFIED {
+		t.Fatalf("Expected a modified account, but %+v found", d.Accounts)
+	}
+
+	ad := d.Accounts[0]
+	if len(ad.Header) != 1 || *ad.Header[0] != (FieldChange{"account_name", "ACCOUNT NAME ************", "RENAMED"}) {
+		t.Errorf("Expected the account name change, but %+v found", ad.Header)
+	}
+	if len(ad.Footer) != 1 || *ad.Footer[0] != (FieldChange{"final_balance", "2301.59", "2000.00"}) {
+		t.Errorf("Expected the final balance change, but %+v found", ad.Footer)
+	}
+
+	if len(ad.Movements) != 3 {
+		t.Fatalf("Expected 3 movement differences, but %d found", len(ad.Movements))
+	}
+	modified, removed, added := ad.Movements[0], ad.Movements[1], ad.Movements[2]
+	if modified.Type != DIFF_MODIFIED || len(modified.Changes) != 1 || *modified.Changes[0] != (FieldChange{"amount", "-23.99", "-24.99"}) {
+		t.Errorf("Expected the amount change, but %+v found", modified)
+	}
+	if removed.Type != DIFF_REMOVED || removed.Old != a.Accounts[0].Movements[4] || removed.New != nil {
+		t.Errorf("Expected the last movement removed, but %+v found", removed)
+	}
+	if added.Type != DIFF_ADDED || added.New.Amount != 10 || added.Old != nil {
+		t.Errorf("Expected the transfer added, but %+v found", added)
+	}
+
+	b.Accounts[0].Header.AccountNumber = "5555666677"
+	d = Diff(a, b)
+	if len(d.Accounts) != 2 || d.Accounts[0].Type != DIFF_REMOVED || d.Accounts[1].Type != DIFF_ADDED {
+		t.Errorf("Expected a removed and an added account, but %+v found", d.Accounts)
+	}
+}
@@ -0,0 +1,49 @@
+package n43
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// Fingerprint returns a deterministic identifier built from the contents of
+// the movement: dates, amount, concepts, document number, references and
+// extra information. The same movement found in two files has the same
+// fingerprint, while the computed balances do not take part in it.
+func (m *Movement) Fingerprint() string {
+	fields := []string{
+		m.TransactionDate.Format("20060102"),
+		m.ValueDate.Format("20060102"),
+		strconv.FormatFloat(m.Amount, 'f', 2, 64),
+		m.CommonConcept,
+		m.OwnConcept,
+		strings.TrimSpace(m.DocumentNumber),
+		strings.TrimSpace(m.Description),
+	}
+	for _, line := range m.ExtraInformation {
+		fields = append(fields, strings.TrimSpace(line))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Fingerprints returns the fingerprints of the account movements, in the same
+// order. Identical movements of the account get a -2, -3, ... suffix so each
+// of them still has its own identifier.
+func (a *Account) Fingerprints() []string {
+	seen := map[string]int{}
+	fingerprints := make([]string, 0, len(a.Movements))
+
+	for _, m := range a.Movements {
+		fingerprint := m.Fingerprint()
+		seen[fingerprint]++
+		if count := seen[fingerprint]; count > 1 {
+			fingerprint = fingerprint + "-" + strconv.Itoa(count)
+		}
+		fingerprints = append(fingerprints, fingerprint)
+	}
+
+	return fingerprints
+}
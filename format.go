@@ -0,0 +1,61 @@
+package n43
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatOptions configures the records written by Format.
+type FormatOptions struct {
+	// CRLF ends the records with CR LF instead of LF.
+	CRLF bool
+	// SkipEndOfFile leaves out the end of file record.
+	SkipEndOfFile bool
+}
+
+// Format returns the canonical form of a Norma43 file: 80 character records
+// ending with LF, or CR LF, footers with the totals of the movements and an
+// end of file record counting every record. The file is read leniently:
+// CR LF line endings, trimmed trailing spaces, blank lines and a missing end
+// of file record are all accepted, but records longer than 80 characters are
+// an error instead of being cut.
+func Format(data []byte, formatOptions *FormatOptions) ([]byte, error) {
+	fo := new(FormatOptions)
+
+	if formatOptions != nil {
+		fo.CRLF = formatOptions.CRLF
+		fo.SkipEndOfFile = formatOptions.SkipEndOfFile
+	}
+
+	lines := []string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			continue
+		}
+		if size := utf8.RuneCountInString(line); size > 80 {
+			return nil, errors.New("line " + strconv.Itoa(i+1) + " is " + strconv.Itoa(size) + " characters long, records have 80")
+		}
+		lines = append(lines, field(line, 80))
+	}
+
+	n, err := NewParser(lines, nil).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, account := range n.Accounts {
+		account.Footer = account.ComputeFooter()
+	}
+
+	var buf bytes.Buffer
+	err = NewWriter(&buf, &WriterOptions{CRLF: fo.CRLF, SkipEndOfFile: fo.SkipEndOfFile}).Write(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,86 @@
+package n43
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_format(t *testing.T) {
+	out, err := Format([]byte(sampleData), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if len(lines) != 14 {
+		t.Fatalf("Expected 14 records, but %d found", len(lines))
+	}
+	for i, line := range lines {
+		if len(line) != 80 {
+			t.Errorf("Expected record %d to be 80 characters long, but %d found", i+1, len(line))
+		}
+	}
+	if !strings.HasPrefix(lines[13], "88999999999999999999000013") {
+		t.Errorf("Expected an end of file record counting 13 records, but %q found", lines[13])
+	}
+
+	n, err := NewParser(lines, nil).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := n.Validate(); len(errs) != 0 {
+		t.Errorf("Expected recomputed footers, but %v found", errs)
+	}
+
+	again, err := Format(out, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, again) {
+		t.Errorf("Expected the canonical form to be kept")
+	}
+}
+
+func Test_formatLenient(t *testing.T) {
+	canonical, err := Format([]byte(sampleData), &FormatOptions{CRLF: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Trimmed records, blank lines, LF endings and no end of file record.
+	lines := strings.Split(sampleData, "\n")
+	messy := []string{}
+	for _, line := range lines[:len(lines)-1] {
+		messy = append(messy, strings.TrimSpace(line), "")
+	}
+
+	out, err := Format([]byte(strings.Join(messy, "\n")), &FormatOptions{CRLF: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, canonical) {
+		t.Errorf("Expected\n%s\nbut\n%s\nfound", canonical, out)
+	}
+
+	out, err = Format([]byte(strings.Join(messy, "\r\n")), &FormatOptions{SkipEndOfFile: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "\r") || strings.Contains(string(out), "\n88") {
+		t.Errorf("Expected LF endings and no end of file record, but\n%s\nfound", out)
+	}
+
+	if _, err := Format([]byte("11123"), nil); err == nil {
+		t.Errorf("Expected an error for a malformed file")
+	}
+}
+
+func Test_formatLongRecord(t *testing.T) {
+	lines := strings.Split(sampleData, "\n")
+	lines[2] += strings.Repeat("X", 20)
+
+	if _, err := Format([]byte(strings.Join(lines, "\n")), nil); err == nil || err.Error() != "line 3 is 89 characters long, records have 80" {
+		t.Errorf("Expected a long record error, but %v found", err)
+	}
+}
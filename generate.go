@@ -0,0 +1,322 @@
+package n43
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GeneratorOptions configures Generate. The fields left at zero take the
+// defaults given in their comments.
+type GeneratorOptions struct {
+	// Seed of the random generator, the same seed and options give the same
+	// document.
+	Seed int64
+	// Accounts is the number of accounts, 1 by default.
+	Accounts int
+	// Movements is the number of movements of each account, 20 by default.
+	Movements int
+	// StartDate and EndDate limit the statement period, January 2020 by
+	// default.
+	StartDate time.Time
+	EndDate   time.Time
+	// Concepts weights the common concept codes of the movements. By default
+	// most of them are card payments and direct debits.
+	Concepts map[string]int
+	// ExtraInformation is the maximum number of records 23 of a movement, up
+	// to 5. It is 2 by default, a negative value leaves them out.
+	ExtraInformation int
+	// Currencies are the ISO 4217 numeric codes given in turn to the
+	// accounts, 978 (EUR) by default.
+	Currencies []string
+}
+
+// generatorConcept describes the movements of a common concept code: their
+// amount range, the sign, the own concept codes and the text of their
+// complementary concepts.
+type generatorConcept struct {
+	min, max float64
+	// sign is -1 for debits, 1 for credits and 0 for both.
+	sign        float64
+	ownConcepts []string
+	texts       []string
+	names       []string
+}
+
+var generatorConcepts = map[string]*generatorConcept{
+	CONCEPT_DEPOSITS:      {50, 2000, 1, []string{"001", "002"}, []string{"INGRESO EFECTIVO"}, generatorCities},
+	CONCEPT_DIRECT_DEBITS: {10, 250, -1, []string{"031", "032", "035"}, []string{"ADEUDO RECIBO", "RECIBO DOMICILIADO"}, generatorCompanies},
+	CONCEPT_TRANSFERS:     {20, 1500, 0, []string{"041", "042"}, []string{"TRANSFERENCIA", "TRANSF. SEPA"}, generatorPeople},
+	CONCEPT_LOANS:         {200, 900, -1, []string{"050"}, []string{"CUOTA PRESTAMO"}, []string{"HIPOTECA", "PRESTAMO PERSONAL"}},
+	CONCEPT_ATM:           {20, 300, -1, []string{"110", "111"}, []string{"REINTEGRO CAJERO"}, generatorCities},
+	CONCEPT_CARDS:         {3, 150, -1, []string{"120", "124"}, []string{"COMPRA TARJ."}, generatorMerchants},
+	CONCEPT_PAYROLL:       {1200, 3500, 1, []string{"150"}, []string{"NOMINA"}, generatorCompanies},
+	CONCEPT_INTEREST_FEES: {1, 30, -1, []string{"170", "171"}, []string{"COMISION MANTENIMIENTO", "LIQUIDACION INTERESES"}, []string{"CUENTA"}},
+	CONCEPT_MISCELLANEOUS: {5, 500, 0, []string{"990"}, []string{"OPERACION VARIOS"}, []string{"VARIOS"}},
+}
+
+var defaultGeneratorConcepts = map[string]int{
+	CONCEPT_CARDS:         40,
+	CONCEPT_DIRECT_DEBITS: 20,
+	CONCEPT_TRANSFERS:     15,
+	CONCEPT_ATM:           8,
+	CONCEPT_INTEREST_FEES: 5,
+	CONCEPT_DEPOSITS:      4,
+	CONCEPT_PAYROLL:       3,
+	CONCEPT_LOANS:         3,
+	CONCEPT_MISCELLANEOUS: 2,
+}
+
+var (
+	generatorBanks     = []string{"0049", "0075", "0081", "0128", "0182", "2100"}
+	generatorHolders   = []string{"EMPRESA EJEMPLO SL", "COMERCIAL DEL NORTE SA", "JUAN PEREZ GARCIA", "MARIA LOPEZ MARTIN", "SERVICIOS TECNICOS SL"}
+	generatorCompanies = []string{"ELECTRICA DEL SUR", "AGUAS MUNICIPALES", "TELEFONICA MOVIL", "SEGUROS GENERALES", "GIMNASIO CENTRAL", "GAS NATURAL"}
+	generatorMerchants = []string{"SUPERMERCADO CENTRO", "GASOLINERA NORTE", "FARMACIA SOL", "LIBRERIA MAYOR", "RESTAURANTE PUERTO", "TIENDA ONLINE"}
+	generatorPeople    = []string{"ANA RUIZ SANZ", "PEDRO GOMEZ DIAZ", "LUIS MORENO GIL", "CARMEN NAVARRO ORTIZ"}
+	generatorCities    = []string{"MADRID", "BARCELONA", "VALENCIA", "SEVILLA", "BILBAO"}
+)
+
+// Generate returns a synthetic document with realistic accounts and
+// movements, with computed balances and footers. It is meant for fixtures
+// and load tests and always passes Validate. Options out of range are
+// reported as errors instead of being replaced by the defaults.
+func Generate(generatorOptions *GeneratorOptions) (*Norma43, error) {
+	gop := new(GeneratorOptions)
+
+	gop.Accounts = 1
+	gop.Movements = 20
+	gop.StartDate = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	gop.EndDate = time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+	gop.Concepts = defaultGeneratorConcepts
+	gop.ExtraInformation = 2
+	gop.Currencies = []string{"978"}
+
+	if generatorOptions != nil {
+		gop.Seed = generatorOptions.Seed
+		if generatorOptions.Accounts != 0 {
+			gop.Accounts = generatorOptions.Accounts
+		}
+		if generatorOptions.Movements != 0 {
+			gop.Movements = generatorOptions.Movements
+		}
+		if !generatorOptions.StartDate.IsZero() {
+			gop.StartDate = generatorOptions.StartDate
+		}
+		if !generatorOptions.EndDate.IsZero() {
+			gop.EndDate = generatorOptions.EndDate
+		}
+		if generatorOptions.Concepts != nil {
+			gop.Concepts = generatorOptions.Concepts
+		}
+		if generatorOptions.ExtraInformation != 0 {
+			gop.ExtraInformation = generatorOptions.ExtraInformation
+		}
+		if generatorOptions.Currencies != nil {
+			gop.Currencies = generatorOptions.Currencies
+		}
+	}
+	if err := gop.validate(); err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		rnd: rand.New(rand.NewSource(gop.Seed)),
+		gop: gop,
+	}
+	for code := range gop.Concepts {
+		if gop.Concepts[code] > 0 {
+			g.concepts = append(g.concepts, code)
+		}
+	}
+	sort.Strings(g.concepts)
+
+	n := &Norma43{Accounts: []*Account{}}
+	for i := 0; i < gop.Accounts; i++ {
+		account := g.account(gop.Currencies[i%len(gop.Currencies)])
+		n.Accounts = append(n.Accounts, account)
+		n.ReportedEntries += account.Records()
+	}
+
+	return n, nil
+}
+
+func (gop *GeneratorOptions) validate() error {
+	if gop.Accounts < 0 {
+		return errors.New("the number of accounts must not be negative")
+	}
+	if gop.Movements < 0 {
+		return errors.New("the number of movements must not be negative")
+	}
+	if gop.EndDate.Before(gop.StartDate) {
+		return errors.New("the end date is before the start date")
+	}
+	if gop.ExtraInformation > 5 {
+		return errors.New("a movement has 5 extra information records at most")
+	}
+
+	weight := 0
+	for code, w := range gop.Concepts {
+		if len(code) != 2 || strings.IndexFunc(code, notDigit) >= 0 {
+			return errors.New("wrong concept code " + code)
+		}
+		if w < 0 {
+			return errors.New("the weight of concept " + code + " is negative")
+		}
+		weight += w
+	}
+	if weight == 0 {
+		return errors.New("no concept has a weight")
+	}
+
+	if len(gop.Currencies) == 0 {
+		return errors.New("no currencies")
+	}
+	for _, currency := range gop.Currencies {
+		if len(currency) != 3 || strings.IndexFunc(currency, notDigit) >= 0 {
+			return errors.New("wrong currency " + currency + ", it must be a 3 digit ISO 4217 numeric code")
+		}
+	}
+
+	return nil
+}
+
+func notDigit(r rune) bool {
+	return r < '0' || r > '9'
+}
+
+type generator struct {
+	rnd      *rand.Rand
+	gop      *GeneratorOptions
+	concepts []string
+}
+
+func (g *generator) account(currency string) *Account {
+	h := &Header{
+		BankCode:            g.pick(generatorBanks),
+		BranchCode:          g.digits(4),
+		AccountNumber:       g.digits(10),
+		StartDate:           g.gop.StartDate,
+		EndDate:             g.gop.EndDate,
+		InitialBalance:      g.amount(1000, 20000),
+		Currency:            currency,
+		InformationModeCode: "3",
+		AccountName:         g.pick(generatorHolders),
+	}
+	a := &Account{Header: h}
+
+	days := int(h.EndDate.Sub(h.StartDate).Hours()/24) + 1
+	dates := make([]time.Time, g.gop.Movements)
+	for i := range dates {
+		dates[i] = h.StartDate.AddDate(0, 0, g.rnd.Intn(days))
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	balance := h.InitialBalance
+	for _, date := range dates {
+		m := g.movement(h, date)
+		balance += m.Amount
+		m.Balance = roundAmount(balance)
+		m.FilteredSum = roundAmount(balance - h.InitialBalance)
+		a.Movements = append(a.Movements, m)
+	}
+
+	a.Footer = a.ComputeFooter()
+
+	return a
+}
+
+func (g *generator) movement(h *Header, date time.Time) *Movement {
+	code := g.concept()
+	gc, ok := generatorConcepts[code]
+	if !ok {
+		gc = generatorConcepts[CONCEPT_MISCELLANEOUS]
+	}
+
+	sign := gc.sign
+	if sign == 0 {
+		sign = 1
+		if g.rnd.Intn(2) == 0 {
+			sign = -1
+		}
+	}
+
+	valueDate := date
+	if g.rnd.Intn(4) == 0 {
+		valueDate = date.AddDate(0, 0, 1+g.rnd.Intn(2))
+	}
+
+	m := &Movement{
+		BranchCode:      h.BranchCode,
+		TransactionDate: date,
+		ValueDate:       valueDate,
+		CommonConcept:   code,
+		OwnConcept:      g.pick(gc.ownConcepts),
+		Amount:          sign * g.amount(gc.min, gc.max),
+		DocumentNumber:  "0000000000",
+		Description:     g.digits(12) + g.digits(16),
+	}
+	if code == CONCEPT_ATM {
+		m.Amount = sign * math.Round(m.Amount/10) * 10
+	}
+	if g.rnd.Intn(3) == 0 {
+		m.DocumentNumber = g.digits(10)
+	}
+
+	if g.gop.ExtraInformation > 0 {
+		texts := []string{g.pick(gc.texts) + " " + g.pick(gc.names)}
+		if code == CONCEPT_CARDS {
+			texts[0] = g.pick(gc.texts) + " " + g.digits(4) + "XXXXXXXX" + g.digits(4)
+			texts = append(texts, g.pick(gc.names))
+		}
+		lines := 1 + g.rnd.Intn(g.gop.ExtraInformation)
+		for len(texts) < lines*2 && g.rnd.Intn(2) == 0 {
+			texts = append(texts, fmt.Sprintf("REF. %s", g.digits(12)))
+		}
+		m.ExtraInformation = extraInformation(texts)
+		if len(m.ExtraInformation) > g.gop.ExtraInformation {
+			m.ExtraInformation = m.ExtraInformation[:g.gop.ExtraInformation]
+		}
+	}
+
+	return m
+}
+
+// concept picks a common concept code by weight.
+func (g *generator) concept() string {
+	total := 0
+	for _, code := range g.concepts {
+		total += g.gop.Concepts[code]
+	}
+	if total == 0 {
+		return CONCEPT_MISCELLANEOUS
+	}
+
+	r := g.rnd.Intn(total)
+	for _, code := range g.concepts {
+		r -= g.gop.Concepts[code]
+		if r < 0 {
+			return code
+		}
+	}
+	return g.concepts[len(g.concepts)-1]
+}
+
+func (g *generator) amount(min float64, max float64) float64 {
+	return roundAmount(min + g.rnd.Float64()*(max-min))
+}
+
+func (g *generator) digits(size int) string {
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = byte('0' + g.rnd.Intn(10))
+	}
+	return string(b)
+}
+
+func (g *generator) pick(values []string) string {
+	return values[g.rnd.Intn(len(values))]
+}
@@ -0,0 +1,109 @@
+package n43
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_generate(t *testing.T) {
+	opts := &GeneratorOptions{
+		Seed:       42,
+		Accounts:   3,
+		Movements:  50,
+		StartDate:  time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:    time.Date(2021, 5, 31, 0, 0, 0, 0, time.UTC),
+		Currencies: []string{"978", "840"},
+	}
+	out, err := Generate(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out.Accounts) != 3 {
+		t.Fatalf("Expected 3 accounts, but %d found", len(out.Accounts))
+	}
+	for i, account := range out.Accounts {
+		if len(account.Movements) != 50 {
+			t.Errorf("Expected 50 movements in account %d, but %d found", i, len(account.Movements))
+		}
+		for _, m := range account.Movements {
+			if m.TransactionDate.Before(opts.StartDate) || m.TransactionDate.After(opts.EndDate) {
+				t.Errorf("Expected movements within the period, but %s found", m.TransactionDate)
+			}
+			if len(m.ExtraInformation) > 2 {
+				t.Errorf("Expected at most 2 extra information records, but %d found", len(m.ExtraInformation))
+			}
+		}
+	}
+	if out.Accounts[0].Header.Currency != "978" || out.Accounts[1].Header.Currency != "840" || out.Accounts[2].Header.Currency != "978" {
+		t.Errorf("Expected the currencies in turn")
+	}
+
+	if errs := out.Validate(); len(errs) != 0 {
+		t.Errorf("Expected a valid document, but %v found", errs)
+	}
+
+	if again, _ := Generate(opts); !reflect.DeepEqual(out, again) {
+		t.Errorf("Expected the same document with the same seed")
+	}
+	if other, _ := Generate(&GeneratorOptions{Seed: 43}); reflect.DeepEqual(out.Accounts[0].Header, other.Accounts[0].Header) {
+		t.Errorf("Expected another document with another seed")
+	}
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf, nil).Write(out); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := NewParser(strings.Split(buf.String(), "\n"), &ParserOptions{Trim: true}).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := parsed.Validate(); len(errs) != 0 || len(parsed.Accounts) != 3 {
+		t.Errorf("Expected the written document to parse and validate, but %v found", errs)
+	}
+}
+
+func Test_generateConcepts(t *testing.T) {
+	out, err := Generate(&GeneratorOptions{Concepts: map[string]int{CONCEPT_PAYROLL: 1}, ExtraInformation: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range out.Accounts[0].Movements {
+		if m.CommonConcept != CONCEPT_PAYROLL || m.Amount <= 0 {
+			t.Errorf("Expected payroll credits, but %s %.2f found", m.CommonConcept, m.Amount)
+		}
+		if len(m.ExtraInformation) != 0 {
+			t.Errorf("Expected no extra information, but %v found", m.ExtraInformation)
+		}
+	}
+}
+
+func Test_generateOptions(t *testing.T) {
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		opts     *GeneratorOptions
+		expected string
+	}{
+		{&GeneratorOptions{Accounts: -1}, "the number of accounts must not be negative"},
+		{&GeneratorOptions{Movements: -5}, "the number of movements must not be negative"},
+		{&GeneratorOptions{StartDate: start, EndDate: start.AddDate(0, 0, -1)}, "the end date is before the start date"},
+		{&GeneratorOptions{ExtraInformation: 6}, "a movement has 5 extra information records at most"},
+		{&GeneratorOptions{Concepts: map[string]int{"1": 1}}, "wrong concept code 1"},
+		{&GeneratorOptions{Concepts: map[string]int{CONCEPT_CARDS: -1, CONCEPT_ATM: 2}}, "the weight of concept 12 is negative"},
+		{&GeneratorOptions{Concepts: map[string]int{}}, "no concept has a weight"},
+		{&GeneratorOptions{Currencies: []string{}}, "no currencies"},
+		{&GeneratorOptions{Currencies: []string{"978", "EUR"}}, "wrong currency EUR, it must be a 3 digit ISO 4217 numeric code"},
+		{&GeneratorOptions{Currencies: []string{"97"}}, "wrong currency 97, it must be a 3 digit ISO 4217 numeric code"},
+	}
+
+	for _, test := range tests {
+		if _, err := Generate(test.opts); err == nil || err.Error() != test.expected {
+			t.Errorf("Expected %q, but %v found", test.expected, err)
+		}
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/Xumeiquer/n43
 
 go 1.20
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,179 @@
+package n43
+
+import (
+	"html/template"
+	"io"
+	"time"
+)
+
+// HTMLWriter writes a Norma43 document as a self-contained HTML report with
+// one section per account, ready to be opened offline or sent by email.
+type HTMLWriter struct {
+	w          io.Writer
+	htmlOption *HTMLOptions
+}
+
+type HTMLOptions struct {
+	// Title of the report, "Account statement" by default.
+	Title string
+	// SkipValidation leaves out the validation warnings.
+	SkipValidation bool
+}
+
+type htmlReport struct {
+	Title     string
+	Generated time.Time
+	Accounts  []*htmlAccount
+	Warnings  []*ValidationError
+}
+
+type htmlAccount struct {
+	*Account
+	IBAN     string
+	Currency string
+	Footer   *Footer
+	Warnings []*ValidationError
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap(TemplateFuncs())).Parse(htmlReportTemplate))
+
+func NewHTMLWriter(w io.Writer, htmlOptions *HTMLOptions) *HTMLWriter {
+	ho := new(HTMLOptions)
+
+	ho.Title = "Account statement"
+
+	if htmlOptions != nil {
+		if htmlOptions.Title != "" {
+			ho.Title = htmlOptions.Title
+		}
+		ho.SkipValidation = htmlOptions.SkipValidation
+	}
+
+	return &HTMLWriter{
+		w:          w,
+		htmlOption: ho,
+	}
+}
+
+func (hw *HTMLWriter) Write(n *Norma43) error {
+	report := &htmlReport{
+		Title:     hw.htmlOption.Title,
+		Generated: time.Now(),
+	}
+
+	var warnings []*ValidationError
+	if !hw.htmlOption.SkipValidation {
+		warnings = n.Validate()
+	}
+
+	for i, account := range n.Accounts {
+		a := &htmlAccount{
+			Account: account,
+			Footer:  account.Footer,
+		}
+		if account.Header != nil {
+			a.IBAN = account.Header.IBAN()
+			a.Currency = CurrencyCode(account.Header.Currency)
+		}
+		if a.Footer == nil {
+			a.Footer = account.ComputeFooter()
+		}
+		for _, warning := range warnings {
+			if warning.Account == i {
+				a.Warnings = append(a.Warnings, warning)
+			}
+		}
+		report.Accounts = append(report.Accounts, a)
+	}
+
+	for _, warning := range warnings {
+		if warning.Account < 0 {
+			report.Warnings = append(report.Warnings, warning)
+		}
+	}
+
+	return htmlTemplate.Execute(hw.w, report)
+}
+
+const htmlReportTemplate = `<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 13px; color: #222; margin: 24px; }
+h1 { font-size: 20px; margin: 0 0 4px; }
+h2 { font-size: 16px; margin: 32px 0 8px; border-bottom: 2px solid #2c5282; padding-bottom: 4px; }
+.generated { color: #777; font-size: 11px; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 2px 16px; margin: 8px 0 16px; }
+dt { color: #555; }
+dd { margin: 0; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 12px; }
+th { background: #2c5282; color: #fff; text-align: left; padding: 6px; font-weight: normal; }
+td { padding: 5px 6px; border-bottom: 1px solid #e2e8f0; vertical-align: top; }
+td.num, th.num { text-align: right; white-space: nowrap; }
+tr.debit td.amount { color: #c53030; }
+tr.credit td.amount { color: #2f855a; }
+tr.debit { background: #fff5f5; }
+tr.credit { background: #f0fff4; }
+.extra { color: #555; font-size: 11px; white-space: pre-line; }
+tfoot td { font-weight: bold; border-top: 2px solid #2c5282; border-bottom: none; }
+.warnings { background: #fffbea; border: 1px solid #d69e2e; color: #744210; padding: 8px 12px; margin: 8px 0; }
+.warnings ul { margin: 4px 0; padding-left: 20px; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<div class="generated">Generated on {{ .Generated.Format "02/01/2006 15:04" }}</div>
+{{- if .Warnings }}
+<div class="warnings"><strong>Warnings</strong><ul>
+{{- range .Warnings }}
+<li>{{ .Message }}</li>
+{{- end }}
+</ul></div>
+{{- end }}
+{{- range .Accounts }}
+{{- $currency := .Currency }}
+<h2>{{ .IBAN }}{{ with .Header }} &middot; {{ trim .AccountName }}{{ end }}</h2>
+{{- with .Header }}
+<dl>
+<dt>Bank</dt><dd>{{ .BankCode }}</dd>
+<dt>Branch</dt><dd>{{ .BranchCode }}</dd>
+<dt>Account</dt><dd>{{ .AccountNumber }}</dd>
+<dt>Period</dt><dd>{{ date .StartDate "DMY" }} &ndash; {{ date .EndDate "DMY" }}</dd>
+<dt>Initial balance</dt><dd>{{ amount .InitialBalance "es" }} {{ $currency }}</dd>
+</dl>
+{{- end }}
+{{- if .Warnings }}
+<div class="warnings"><strong>Validation warnings</strong><ul>
+{{- range .Warnings }}
+<li>{{ .Message }}</li>
+{{- end }}
+</ul></div>
+{{- end }}
+<table>
+<thead><tr><th>Date</th><th>Value date</th><th>Concept</th><th>Description</th><th class="num">Amount</th><th class="num">Balance</th></tr></thead>
+<tbody>
+{{- range .Movements }}
+<tr class="{{ if lt .Amount 0.0 }}debit{{ else }}credit{{ end }}">
+<td>{{ date .TransactionDate "DMY" }}</td>
+<td>{{ date .ValueDate "DMY" }}</td>
+<td>{{ concept .CommonConcept }}</td>
+<td>{{ trim .Description }}{{ with concepts . }}<div class="extra">{{ join "\n" . }}</div>{{ end }}</td>
+<td class="num amount">{{ amount .Amount "es" }}</td>
+<td class="num">{{ amount .Balance "es" }}</td>
+</tr>
+{{- end }}
+</tbody>
+{{- with .Footer }}
+<tfoot>
+<tr><td colspan="4">Debits ({{ .DebitEntries }})</td><td class="num">{{ amount .DebitAmount "es" }}</td><td></td></tr>
+<tr><td colspan="4">Credits ({{ .CreditEntries }})</td><td class="num">{{ amount .CreditAmount "es" }}</td><td></td></tr>
+<tr><td colspan="4">Final balance</td><td></td><td class="num">{{ amount .FinalBalance "es" }} {{ $currency }}</td></tr>
+</tfoot>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`
@@ -0,0 +1,42 @@
+package n43
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_html(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewHTMLWriter(&buf, nil).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{
+		"<title>Account statement</title>",
+		"<h2>ES4811112222033333444412 &middot; ACCOUNT NAME ************</h2>",
+		`<tr class="debit">`,
+		`<tr class="credit">`,
+		`<td class="num amount">-23,99</td>`,
+		`<td class="num">2.439,44</td>`,
+		"footer reports 15 debit entries but 4 found",
+		`<td class="num">2.301,59 EUR</td>`,
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in the HTML output, but %q found", s, out)
+		}
+	}
+
+	if strings.Contains(out, "http") {
+		t.Errorf("Expected a self-contained report, but an external reference found")
+	}
+
+	buf.Reset()
+	if err := NewHTMLWriter(&buf, &HTMLOptions{Title: "February", SkipValidation: true}).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<title>February</title>") || strings.Contains(buf.String(), "warnings") && strings.Contains(buf.String(), "<li>") {
+		t.Errorf("Expected a titled report without warnings, but %q found", buf.String())
+	}
+}
@@ -0,0 +1,89 @@
+package n43
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var cccWeights = []int{1, 2, 4, 8, 5, 10, 9, 7, 3, 6}
+
+// cccDigit returns the check digit of the ten digits of a CCC part.
+func cccDigit(digits string) string {
+	sum := 0
+	for i, r := range digits {
+		sum += int(r-'0') * cccWeights[i]
+	}
+
+	d := 11 - sum%11
+	switch d {
+	case 11:
+		d = 0
+	case 10:
+		d = 1
+	}
+	return strconv.Itoa(d)
+}
+
+// CCCCheckDigits returns the two check digits of the Spanish CCC (Código
+// Cuenta Cliente) made of the bank code, the branch code and the account
+// number.
+func CCCCheckDigits(bankCode string, branchCode string, accountNumber string) string {
+	return cccDigit("00"+bankCode+branchCode) + cccDigit(accountNumber)
+}
+
+// IBANCheckDigits returns the check digits of the IBAN with the given country
+// code and basic bank account number.
+func IBANCheckDigits(country string, bban string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(bban + country + "00") {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteString(strconv.Itoa(int(r-'A') + 10))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	n, ok := new(big.Int).SetString(b.String(), 10)
+	if !ok {
+		return ""
+	}
+	mod := new(big.Int).Mod(n, big.NewInt(97)).Int64()
+
+	check := strconv.Itoa(int(98 - mod))
+	if len(check) == 1 {
+		check = "0" + check
+	}
+	return check
+}
+
+// CCC returns the 20 digits Spanish account code of the header account.
+func (h *Header) CCC() string {
+	return h.BankCode + h.BranchCode + CCCCheckDigits(h.BankCode, h.BranchCode, h.AccountNumber) + h.AccountNumber
+}
+
+// IBAN returns the Spanish IBAN of the header account.
+func (h *Header) IBAN() string {
+	ccc := h.CCC()
+	return "ES" + IBANCheckDigits("ES", ccc) + ccc
+}
+
+// SplitAccountID splits a Spanish IBAN or CCC into the bank code, the branch
+// code and the account number. Other identifications only fill the account
+// number with their last ten characters.
+func SplitAccountID(id string) (string, string, string) {
+	id = strings.ReplaceAll(id, " ", "")
+
+	if len(id) == 24 && strings.HasPrefix(id, "ES") {
+		id = id[4:]
+	}
+
+	if len(id) == 20 && strings.Trim(id, "0123456789") == "" {
+		return id[0:4], id[4:8], id[10:20]
+	}
+
+	if len(id) > 10 {
+		id = id[len(id)-10:]
+	}
+	return "", "", id
+}
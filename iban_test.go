@@ -0,0 +1,15 @@
+package n43
+
+import "testing"
+
+func Test_iban(t *testing.T) {
+	h := &Header{BankCode: "2100", BranchCode: "0418", AccountNumber: "0200051332"}
+
+	if ccc := h.CCC(); ccc != "21000418450200051332" {
+		t.Errorf("Expected CCC to be 21000418450200051332, but %s found", ccc)
+	}
+
+	if iban := h.IBAN(); iban != "ES9121000418450200051332" {
+		t.Errorf("Expected IBAN to be ES9121000418450200051332, but %s found", iban)
+	}
+}
@@ -0,0 +1,100 @@
+package n43
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// jsonDate is the layout of the dates in JSON. Norma43 dates carry no time,
+// so they are written as ISO 8601 dates instead of timestamps.
+const jsonDate = "2006-01-02"
+
+// header and movement are Header and Movement without their JSON methods.
+type (
+	header   Header
+	movement Movement
+)
+
+// jsonHeader and jsonMovement embed every field of Header and Movement and
+// replace their dates with text.
+type jsonHeader struct {
+	*header
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+type jsonMovement struct {
+	*movement
+	TransactionDate string `json:"transaction_date"`
+	ValueDate       string `json:"value_date"`
+}
+
+func (h Header) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonHeader{
+		header:    (*header)(&h),
+		StartDate: h.StartDate.Format(jsonDate),
+		EndDate:   h.EndDate.Format(jsonDate),
+	})
+}
+
+func (h *Header) UnmarshalJSON(data []byte) error {
+	v := &jsonHeader{header: new(header)}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	startDate, err := parseJSONDate(v.StartDate)
+	if err != nil {
+		return err
+	}
+	endDate, err := parseJSONDate(v.EndDate)
+	if err != nil {
+		return err
+	}
+
+	*h = Header(*v.header)
+	h.StartDate = startDate
+	h.EndDate = endDate
+	return nil
+}
+
+func (m Movement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonMovement{
+		movement:        (*movement)(&m),
+		TransactionDate: m.TransactionDate.Format(jsonDate),
+		ValueDate:       m.ValueDate.Format(jsonDate),
+	})
+}
+
+func (m *Movement) UnmarshalJSON(data []byte) error {
+	v := &jsonMovement{movement: new(movement)}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	transactionDate, err := parseJSONDate(v.TransactionDate)
+	if err != nil {
+		return err
+	}
+	valueDate, err := parseJSONDate(v.ValueDate)
+	if err != nil {
+		return err
+	}
+
+	*m = Movement(*v.movement)
+	m.TransactionDate = transactionDate
+	m.ValueDate = valueDate
+	return nil
+}
+
+// parseJSONDate parses a JSON date, also accepting the RFC 3339 timestamps
+// written by earlier versions.
+func parseJSONDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(jsonDate, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
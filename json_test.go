@@ -0,0 +1,55 @@
+package n43
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_json(t *testing.T) {
+	n := parseSample(t)
+
+	data, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"start_date":"2020-02-03"`, `"end_date":"2020-02-10"`, `"transaction_date":"2020-02-03","value_date":"2020-02-04"`} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("Expected %s in the JSON, but it is not found", s)
+		}
+	}
+
+	out := new(Norma43)
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(n, out) {
+		t.Errorf("Expected the document to survive a JSON round trip, but %+v found", out)
+	}
+
+	// Every field is written, also the ones added after the date handling.
+	data, err = json.Marshal(&Movement{Category: "Bills", Tags: []string{"home"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	movementType := reflect.TypeOf(Movement{})
+	for i := 0; i < movementType.NumField(); i++ {
+		name := strings.Split(movementType.Field(i).Tag.Get("json"), ",")[0]
+		if _, ok := fields[name]; !ok {
+			t.Errorf("Expected %s in the movement JSON, but it is not found", name)
+		}
+	}
+
+	m := new(Movement)
+	if err := json.Unmarshal([]byte(`{"transaction_date":"2020-02-03T00:00:00Z"}`), m); err != nil || m.TransactionDate.Format(jsonDate) != "2020-02-03" {
+		t.Errorf("Expected RFC 3339 dates to be read, but %v found", err)
+	}
+	if err := json.Unmarshal([]byte(`{"value_date":"03/02/2020"}`), m); err == nil {
+		t.Errorf("Expected an error with an invalid date, but nothing found")
+	}
+}
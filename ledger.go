@@ -0,0 +1,372 @@
+package n43
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// LedgerMapping tells the plain text accounting writers which ledger account
+// to use for each bank account and for the counterpart of each movement.
+type LedgerMapping struct {
+	// Accounts maps an IBAN, a CCC or an account number to a ledger account.
+	Accounts map[string]string `json:"accounts"`
+	// DefaultAccount is used for the bank accounts missing in Accounts. By
+	// default it is Assets:Bank: followed by the IBAN.
+	DefaultAccount string `json:"default_account"`
+	// Rules choose the counter-account of the movements, the first matching
+	// rule wins.
+	Rules []*LedgerRule `json:"rules"`
+	// DefaultDebit and DefaultCredit are the counter-accounts of the
+	// movements no rule matches, Expenses:Unknown and Income:Unknown by
+	// default.
+	DefaultDebit  string `json:"default_debit"`
+	DefaultCredit string `json:"default_credit"`
+	// OpeningAccount is the counter-account of the opening balances,
+	// Equity:Opening-Balances by default.
+	OpeningAccount string `json:"opening_account"`
+}
+
+// LedgerRule matches movements by their common concept code, the sign of
+// their amount and a regular expression run against their description and
+// extra information. Empty fields match every movement.
+type LedgerRule struct {
+	Concept     string `json:"concept"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Account     string `json:"account"`
+	descRe      *regexp.Regexp
+}
+
+type LedgerOptions struct {
+	Mapping *LedgerMapping
+	// OpenAccounts writes the directives opening every account used, only
+	// for Beancount.
+	OpenAccounts bool
+}
+
+// BeancountWriter writes a Norma43 document as a Beancount journal.
+type BeancountWriter struct {
+	w            *bufio.Writer
+	ledgerOption *LedgerOptions
+}
+
+// LedgerWriter writes a Norma43 document as a Ledger journal, also readable
+// by hledger.
+type LedgerWriter struct {
+	w            *bufio.Writer
+	ledgerOption *LedgerOptions
+}
+
+// LoadLedgerMapping reads a JSON ledger mapping and compiles its rules.
+func LoadLedgerMapping(r io.Reader) (*LedgerMapping, error) {
+	mapping := new(LedgerMapping)
+	if err := json.NewDecoder(r).Decode(mapping); err != nil {
+		return nil, err
+	}
+	if err := mapping.compile(); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+func (lm *LedgerMapping) compile() error {
+	for i, rule := range lm.Rules {
+		if rule.Account == "" {
+			return errors.New("ledger rule " + strconv.Itoa(i) + " has no account")
+		}
+		if rule.Type != "" && rule.Type != "debit" && rule.Type != "credit" {
+			return errors.New("ledger rule " + strconv.Itoa(i) + " has an invalid type " + rule.Type)
+		}
+		if rule.Description != "" && rule.descRe == nil {
+			re, err := regexp.Compile(rule.Description)
+			if err != nil {
+				return err
+			}
+			rule.descRe = re
+		}
+	}
+	return nil
+}
+
+// Account returns the ledger account of the bank account of h.
+func (lm *LedgerMapping) Account(h *Header) string {
+	for _, id := range []string{h.IBAN(), h.CCC(), h.AccountNumber} {
+		if account, ok := lm.Accounts[id]; ok {
+			return account
+		}
+	}
+
+	if lm.DefaultAccount != "" {
+		return lm.DefaultAccount
+	}
+	return "Assets:Bank:" + h.IBAN()
+}
+
+// CounterAccount returns the counter-account of m: the account of the first
+// matching rule, else the account of its category, else the defaults.
+func (lm *LedgerMapping) CounterAccount(m *Movement) string {
+	text := strings.Join(append([]string{m.Description}, m.ExtraInformation...), "\n")
+
+	for _, rule := range lm.Rules {
+		if rule.Concept != "" && rule.Concept != m.CommonConcept {
+			continue
+		}
+		if rule.Type == "debit" && m.Amount >= 0 || rule.Type == "credit" && m.Amount < 0 {
+			continue
+		}
+		if rule.descRe != nil && !rule.descRe.MatchString(text) {
+			continue
+		}
+		return rule.Account
+	}
+
+	if m.Category != "" {
+		return categoryAccount(m.Category, m.Amount)
+	}
+
+	if m.Amount < 0 {
+		if lm.DefaultDebit != "" {
+			return lm.DefaultDebit
+		}
+		return "Expenses:Unknown"
+	}
+	if lm.DefaultCredit != "" {
+		return lm.DefaultCredit
+	}
+	return "Income:Unknown"
+}
+
+// categoryAccount returns the ledger account of a category, the category
+// itself when it is a full account name like Expenses:Food, or else the
+// category under Expenses or Income by the sign of amount. Spaces are not
+// allowed in Beancount account names and are replaced by dashes.
+func categoryAccount(category string, amount float64) string {
+	account := strings.Join(strings.Fields(category), "-")
+	if strings.Contains(account, ":") {
+		return account
+	}
+	if amount < 0 {
+		return "Expenses:" + account
+	}
+	return "Income:" + account
+}
+
+// openingAccount returns the counter-account of the opening balances.
+func (lm *LedgerMapping) openingAccount() string {
+	if lm.OpeningAccount != "" {
+		return lm.OpeningAccount
+	}
+	return "Equity:Opening-Balances"
+}
+
+func newLedgerOptions(ledgerOptions *LedgerOptions) *LedgerOptions {
+	lo := new(LedgerOptions)
+
+	lo.Mapping = new(LedgerMapping)
+
+	if ledgerOptions != nil {
+		lo.OpenAccounts = ledgerOptions.OpenAccounts
+		if ledgerOptions.Mapping != nil {
+			lo.Mapping = ledgerOptions.Mapping
+		}
+	}
+
+	return lo
+}
+
+func NewBeancountWriter(w io.Writer, ledgerOptions *LedgerOptions) *BeancountWriter {
+	return &BeancountWriter{
+		w:            bufio.NewWriter(w),
+		ledgerOption: newLedgerOptions(ledgerOptions),
+	}
+}
+
+func NewLedgerWriter(w io.Writer, ledgerOptions *LedgerOptions) *LedgerWriter {
+	return &LedgerWriter{
+		w:            bufio.NewWriter(w),
+		ledgerOption: newLedgerOptions(ledgerOptions),
+	}
+}
+
+func (b *BeancountWriter) Write(n *Norma43) error {
+	if err := b.ledgerOption.Mapping.compile(); err != nil {
+		return err
+	}
+	mapping := b.ledgerOption.Mapping
+
+	if b.ledgerOption.OpenAccounts {
+		b.writeOpen(n)
+	}
+
+	opened := map[string]bool{}
+	for _, account := range n.Accounts {
+		h := account.Header
+		if h == nil {
+			h = new(Header)
+		}
+		bank := mapping.Account(h)
+		ccy := CurrencyCode(h.Currency)
+
+		// The first statement of each account pads it to its initial
+		// balance, the next ones only check it.
+		if !opened[bank] {
+			opened[bank] = true
+			b.w.WriteString(beancountDate(h.StartDate.AddDate(0, 0, -1)) + " pad " + bank + " " + mapping.openingAccount() + "\n")
+		}
+		b.w.WriteString(beancountDate(h.StartDate) + " balance " + bank + "  " + ledgerAmount(h.InitialBalance) + " " + ccy + "\n\n")
+
+		for _, m := range account.Movements {
+			payee, narration := ledgerPayee(m)
+
+			b.w.WriteString(beancountDate(m.TransactionDate) + " * " + beancountString(payee) + " " + beancountString(narration))
+			for _, tag := range m.Tags {
+				b.w.WriteString(" #" + ledgerTag(tag))
+			}
+			b.w.WriteString("\n")
+			b.w.WriteString("  " + bank + "  " + ledgerAmount(m.Amount) + " " + ccy + "\n")
+			b.w.WriteString("  " + mapping.CounterAccount(m) + "\n\n")
+		}
+
+		// Beancount checks the balances at the beginning of the day.
+		b.w.WriteString(beancountDate(h.EndDate.AddDate(0, 0, 1)) + " balance " + bank + "  " + ledgerAmount(accountFinalBalance(account)) + " " + ccy + "\n\n")
+	}
+
+	return b.w.Flush()
+}
+
+func (b *BeancountWriter) writeOpen(n *Norma43) {
+	mapping := b.ledgerOption.Mapping
+
+	var start time.Time
+	accounts := map[string]bool{}
+	for _, account := range n.Accounts {
+		h := account.Header
+		if h == nil {
+			h = new(Header)
+		}
+		if start.IsZero() || h.StartDate.Before(start) {
+			start = h.StartDate
+		}
+
+		accounts[mapping.Account(h)] = true
+		accounts[mapping.openingAccount()] = true
+		for _, m := range account.Movements {
+			accounts[mapping.CounterAccount(m)] = true
+		}
+	}
+
+	names := make([]string, 0, len(accounts))
+	for name := range accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Opened the day before the first statement, when the accounts are
+	// padded.
+	for _, name := range names {
+		b.w.WriteString(beancountDate(start.AddDate(0, 0, -1)) + " open " + name + "\n")
+	}
+	b.w.WriteString("\n")
+}
+
+func (l *LedgerWriter) Write(n *Norma43) error {
+	if err := l.ledgerOption.Mapping.compile(); err != nil {
+		return err
+	}
+	mapping := l.ledgerOption.Mapping
+
+	opened := map[string]bool{}
+	for _, account := range n.Accounts {
+		h := account.Header
+		if h == nil {
+			h = new(Header)
+		}
+		bank := mapping.Account(h)
+		ccy := CurrencyCode(h.Currency)
+
+		// The first statement of each account assigns its initial balance
+		// against the opening account, the next ones only check it.
+		if !opened[bank] {
+			opened[bank] = true
+			l.w.WriteString(ledgerDate(h.StartDate) + " * Opening balance\n")
+			l.w.WriteString("    " + bank + "  = " + ledgerAmount(h.InitialBalance) + " " + ccy + "\n")
+			l.w.WriteString("    " + mapping.openingAccount() + "\n\n")
+		} else {
+			l.w.WriteString(ledgerDate(h.StartDate) + " * Opening balance assertion\n")
+			l.w.WriteString("    " + bank + "  0 " + ccy + " = " + ledgerAmount(h.InitialBalance) + " " + ccy + "\n\n")
+		}
+
+		for _, m := range account.Movements {
+			payee, narration := ledgerPayee(m)
+
+			l.w.WriteString(ledgerDate(m.TransactionDate) + " * " + payee + "\n")
+			if narration != "" {
+				l.w.WriteString("    ; " + narration + "\n")
+			}
+			if len(m.Tags) > 0 {
+				tags := make([]string, 0, len(m.Tags))
+				for _, tag := range m.Tags {
+					tags = append(tags, ledgerTag(tag))
+				}
+				l.w.WriteString("    ; :" + strings.Join(tags, ":") + ":\n")
+			}
+			l.w.WriteString("    " + bank + "  " + ledgerAmount(m.Amount) + " " + ccy + "\n")
+			l.w.WriteString("    " + mapping.CounterAccount(m) + "\n\n")
+		}
+
+		l.w.WriteString(ledgerDate(h.EndDate) + " * Closing balance assertion\n")
+		l.w.WriteString("    " + bank + "  0 " + ccy + " = " + ledgerAmount(accountFinalBalance(account)) + " " + ccy + "\n\n")
+	}
+
+	return l.w.Flush()
+}
+
+// ledgerPayee returns the payee and the narration of a movement, the first
+// complementary concept and the rest of them with their spaces collapsed.
+// Movements without extra information use their references as payee.
+func ledgerPayee(m *Movement) (string, string) {
+	concepts := m.ComplementaryConcepts()
+	for i, concept := range concepts {
+		concepts[i] = strings.Join(strings.Fields(concept), " ")
+	}
+
+	if len(concepts) == 0 {
+		return strings.TrimSpace(m.Description), ""
+	}
+	return concepts[0], strings.Join(concepts[1:], " ")
+}
+
+// ledgerTag returns a tag with the characters not allowed in Beancount and
+// Ledger tags, like spaces and colons, replaced by dashes.
+func ledgerTag(tag string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_/.", r) {
+			return r
+		}
+		return '-'
+	}, strings.TrimSpace(tag))
+}
+
+func ledgerAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func ledgerDate(t time.Time) string {
+	return t.Format("2006/01/02")
+}
+
+func beancountDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func beancountString(s string) string {
+	return strconv.Quote(s)
+}
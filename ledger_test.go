@@ -0,0 +1,155 @@
+package n43
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testLedgerMapping = `{
+	"accounts": {"ES4811112222033333444412": "Assets:Bank:Checking"},
+	"rules": [
+		{"description": "INSURANCE", "account": "Expenses:Insurance"},
+		{"concept": "12", "type": "credit", "account": "Income:Refunds"},
+		{"concept": "12", "description": "SUPERMARKET|GARAGE", "account": "Expenses:Shopping"}
+	]
+}`
+
+func Test_ledgerMapping(t *testing.T) {
+	mapping, err := LoadLedgerMapping(strings.NewReader(testLedgerMapping))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := parseSample(t)
+	if account := mapping.Account(out.Accounts[0].Header); account != "Assets:Bank:Checking" {
+		t.Errorf("Expected Assets:Bank:Checking, but %s found", account)
+	}
+
+	expected := []string{"Expenses:Unknown", "Expenses:Insurance", "Expenses:Insurance", "Income:Refunds", "Expenses:Shopping"}
+	for i, m := range out.Accounts[0].Movements {
+		if account := mapping.CounterAccount(m); account != expected[i] {
+			t.Errorf("Expected counter-account %s for movement %d, but %s found", expected[i], i, account)
+		}
+	}
+
+	categorized := parseSample(t).Accounts[0].Movements
+	categorized[0].Category = "Large purchases"
+	categorized[1].Category = "Expenses:Bills"
+	categorized[3].Category = "Refunds"
+	expected = []string{"Expenses:Large-purchases", "Expenses:Insurance", "Expenses:Insurance", "Income:Refunds", "Expenses:Shopping"}
+	for i, m := range categorized {
+		if account := mapping.CounterAccount(m); account != expected[i] {
+			t.Errorf("Expected counter-account %s for categorized movement %d, but %s found", expected[i], i, account)
+		}
+	}
+	if account := new(LedgerMapping).CounterAccount(categorized[1]); account != "Expenses:Bills" {
+		t.Errorf("Expected the category as counter-account, but %s found", account)
+	}
+
+	if _, err := LoadLedgerMapping(strings.NewReader(`{"rules": [{"description": "("}]}`)); err == nil {
+		t.Errorf("Expected an error for a rule without account")
+	}
+}
+
+func Test_beancount(t *testing.T) {
+	mapping, err := LoadLedgerMapping(strings.NewReader(testLedgerMapping))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewBeancountWriter(&buf, &LedgerOptions{Mapping: mapping, OpenAccounts: true}).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{
+		"2020-02-02 open Assets:Bank:Checking\n",
+		"2020-02-02 open Equity:Opening-Balances\n",
+		"2020-02-02 pad Assets:Bank:Checking Equity:Opening-Balances\n2020-02-03 balance Assets:Bank:Checking  2463.43 EUR\n",
+		"2020-02-03 * \"INSURANCE COMPANY ABC DEF GHI JKL MNO\" \"PQRS TUVWXYZ\"\n  Assets:Bank:Checking  -70.29 EUR\n  Expenses:Insurance\n",
+		"2020-02-11 balance Assets:Bank:Checking  2301.59 EUR\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in the Beancount output, but %q found", s, out)
+		}
+	}
+}
+
+func Test_ledger(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewLedgerWriter(&buf, nil).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{
+		"2020/02/03 * Opening balance\n    Assets:Bank:ES4811112222033333444412  = 2463.43 EUR\n    Equity:Opening-Balances\n",
+		"2020/02/03 * COMPRA TARG 1234XXXXXXXX3456 SHOP TO B\n    ; UY SEVERAL THINGS IN THERE.\n    Assets:Bank:ES4811112222033333444412  -23.99 EUR\n    Expenses:Unknown\n",
+		"2020/02/10 * Closing balance assertion\n    Assets:Bank:ES4811112222033333444412  0 EUR = 2301.59 EUR\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in the Ledger output, but %q found", s, out)
+		}
+	}
+}
+
+func Test_ledgerOpeningBalance(t *testing.T) {
+	n := parseSample(t)
+	next := *n.Accounts[0]
+	header := *next.Header
+	header.StartDate, header.EndDate = header.EndDate.AddDate(0, 0, 1), header.EndDate.AddDate(0, 0, 7)
+	header.InitialBalance = 2301.59
+	next.Header, next.Movements = &header, nil
+	n.Accounts = append(n.Accounts, &next)
+
+	mapping := &LedgerMapping{OpeningAccount: "Equity:Opening"}
+
+	var buf bytes.Buffer
+	if err := NewLedgerWriter(&buf, &LedgerOptions{Mapping: mapping}).Write(n); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if strings.Count(out, "Equity:Opening") != 1 {
+		t.Errorf("Expected a single opening posting, but %q found", out)
+	}
+	if !strings.Contains(out, "2020/02/11 * Opening balance assertion\n    Assets:Bank:ES4811112222033333444412  0 EUR = 2301.59 EUR\n") {
+		t.Errorf("Expected the next statement to check its initial balance, but %q found", out)
+	}
+
+	buf.Reset()
+	if err := NewBeancountWriter(&buf, &LedgerOptions{Mapping: mapping}).Write(n); err != nil {
+		t.Fatal(err)
+	}
+
+	out = buf.String()
+	if strings.Count(out, " pad ") != 1 || !strings.HasPrefix(out, "2020-02-02 pad Assets:Bank:ES4811112222033333444412 Equity:Opening\n") {
+		t.Errorf("Expected a single pad before the first balance, but %q found", out)
+	}
+	if !strings.Contains(out, "2020-02-11 balance Assets:Bank:ES4811112222033333444412  2301.59 EUR\n") {
+		t.Errorf("Expected the next statement to check its initial balance, but %q found", out)
+	}
+}
+
+func Test_ledgerTags(t *testing.T) {
+	n := parseSample(t)
+	n.Accounts[0].Movements[0].Tags = []string{"card", "home office"}
+
+	var buf bytes.Buffer
+	if err := NewBeancountWriter(&buf, nil).Write(n); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\"UY SEVERAL THINGS IN THERE.\" #card #home-office\n") {
+		t.Errorf("Expected the tags in the Beancount transaction, but %q found", buf.String())
+	}
+
+	buf.Reset()
+	if err := NewLedgerWriter(&buf, nil).Write(n); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "    ; UY SEVERAL THINGS IN THERE.\n    ; :card:home-office:\n") {
+		t.Errorf("Expected the tags in the Ledger transaction, but %q found", buf.String())
+	}
+}
@@ -0,0 +1,285 @@
+package n43
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type LintSeverity string
+
+const (
+	LINT_ERROR   LintSeverity = "error"
+	LINT_WARNING LintSeverity = "warning"
+	LINT_INFO    LintSeverity = "info"
+)
+
+// Identifiers of the lint rules.
+const (
+	LINT_DATE_OUT_OF_PERIOD = "date-out-of-period"
+	LINT_LATE_VALUE_DATE    = "late-value-date"
+	LINT_UNKNOWN_CONCEPT    = "unknown-concept"
+	LINT_CURRENCY_MISMATCH  = "currency-mismatch"
+	LINT_DUPLICATE_MOVEMENT = "duplicate-movement"
+	LINT_NON_ASCII          = "non-ascii"
+	LINT_ZERO_AMOUNT        = "zero-amount"
+)
+
+// LintIssue is a finding of a lint rule. Movement is the index of the
+// movement within the account, or -1 when the issue is about the account.
+type LintIssue struct {
+	Rule     string       `json:"rule"`
+	Severity LintSeverity `json:"severity"`
+	Account  int          `json:"account"`
+	Movement int          `json:"movement"`
+	Message  string       `json:"message"`
+}
+
+func (i *LintIssue) Error() string {
+	location := "account " + strconv.Itoa(i.Account+1)
+	if i.Movement >= 0 {
+		location += ", movement " + strconv.Itoa(i.Movement+1)
+	}
+	return location + ": " + string(i.Severity) + ": " + i.Message + " (" + i.Rule + ")"
+}
+
+// LintRule describes a lint rule and its default severity.
+type LintRule struct {
+	ID          string
+	Severity    LintSeverity
+	Description string
+	check       func(a *Account, lo *LintOptions) []*lintFinding
+}
+
+type lintFinding struct {
+	movement int
+	message  string
+}
+
+var lintRules = []*LintRule{
+	{LINT_DATE_OUT_OF_PERIOD, LINT_WARNING, "Movement transaction date outside the statement period.", lintDateOutOfPeriod},
+	{LINT_LATE_VALUE_DATE, LINT_WARNING, "Value date long after the transaction date.", lintLateValueDate},
+	{LINT_UNKNOWN_CONCEPT, LINT_WARNING, "Common concept code not defined by the AEB.", lintUnknownConcept},
+	{LINT_CURRENCY_MISMATCH, LINT_ERROR, "Footer currency differs from the header currency.", lintCurrencyMismatch},
+	{LINT_DUPLICATE_MOVEMENT, LINT_WARNING, "Movement identical to a previous one of the account.", lintDuplicateMovement},
+	{LINT_NON_ASCII, LINT_INFO, "Text with characters outside ASCII, which some banks reject.", lintNonASCII},
+	{LINT_ZERO_AMOUNT, LINT_INFO, "Movement with a zero amount.", lintZeroAmount},
+}
+
+// LintRules returns a copy of every lint rule, in the order they are
+// checked. Changing them does not change the rules Lint uses, see
+// LintOptions for that.
+func LintRules() []*LintRule {
+	rules := make([]*LintRule, len(lintRules))
+	for i, rule := range lintRules {
+		r := *rule
+		rules[i] = &r
+	}
+	return rules
+}
+
+// LintOptions configures the linter. It can be read from a JSON file with
+// LoadLintOptions, like:
+//
+//	{
+//	  "rules": {"non-ascii": false},
+//	  "severities": {"zero-amount": "error"},
+//	  "max_value_date_delay": 10
+//	}
+type LintOptions struct {
+	// Rules enables or disables rules by ID. Every rule is enabled by
+	// default.
+	Rules map[string]bool `json:"rules"`
+	// Severities overrides the default severity of rules by ID.
+	Severities map[string]LintSeverity `json:"severities"`
+	// MaxValueDateDelay is the number of days the value date can follow the
+	// transaction date, 5 when nil. Zero warns about any value date after
+	// the transaction date.
+	MaxValueDateDelay *int `json:"max_value_date_delay"`
+}
+
+// LoadLintOptions reads lint options from JSON and checks that they name
+// known rules and severities.
+func LoadLintOptions(r io.Reader) (*LintOptions, error) {
+	lo := new(LintOptions)
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(lo); err != nil {
+		return nil, errors.New("wrong lint options: " + err.Error())
+	}
+
+	if lo.MaxValueDateDelay != nil && *lo.MaxValueDateDelay < 0 {
+		return nil, errors.New("the maximum value date delay must not be negative")
+	}
+	for id := range lo.Rules {
+		if lintRule(id) == nil {
+			return nil, errors.New("unknown lint rule " + id)
+		}
+	}
+	for id, severity := range lo.Severities {
+		if lintRule(id) == nil {
+			return nil, errors.New("unknown lint rule " + id)
+		}
+		if severity != LINT_ERROR && severity != LINT_WARNING && severity != LINT_INFO {
+			return nil, errors.New("unknown lint severity " + string(severity))
+		}
+	}
+
+	return lo, nil
+}
+
+func lintRule(id string) *LintRule {
+	for _, rule := range lintRules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Lint checks the document against the enabled lint rules. Unlike Validate,
+// its issues are about suspicious contents rather than inconsistent totals.
+// The issues are sorted by account and movement.
+func (n *Norma43) Lint(lintOptions *LintOptions) []*LintIssue {
+	lo := new(LintOptions)
+
+	delay := 5
+	lo.MaxValueDateDelay = &delay
+
+	if lintOptions != nil {
+		lo.Rules = lintOptions.Rules
+		lo.Severities = lintOptions.Severities
+		if lintOptions.MaxValueDateDelay != nil {
+			lo.MaxValueDateDelay = lintOptions.MaxValueDateDelay
+		}
+	}
+
+	issues := []*LintIssue{}
+	for i, account := range n.Accounts {
+		// Account issues first, then one slot per movement.
+		byMovement := make([][]*LintIssue, len(account.Movements)+1)
+
+		for _, rule := range lintRules {
+			if enabled, ok := lo.Rules[rule.ID]; ok && !enabled {
+				continue
+			}
+			severity := rule.Severity
+			if s, ok := lo.Severities[rule.ID]; ok {
+				severity = s
+			}
+
+			for _, f := range rule.check(account, lo) {
+				byMovement[f.movement+1] = append(byMovement[f.movement+1], &LintIssue{
+					Rule:     rule.ID,
+					Severity: severity,
+					Account:  i,
+					Movement: f.movement,
+					Message:  f.message,
+				})
+			}
+		}
+
+		for _, found := range byMovement {
+			issues = append(issues, found...)
+		}
+	}
+
+	return issues
+}
+
+func lintDateOutOfPeriod(a *Account, lo *LintOptions) []*lintFinding {
+	findings := []*lintFinding{}
+	if a.Header == nil {
+		return findings
+	}
+	for i, m := range a.Movements {
+		if m.TransactionDate.Before(a.Header.StartDate) || m.TransactionDate.After(a.Header.EndDate) {
+			findings = append(findings, &lintFinding{i, fmt.Sprintf("transaction date %s is outside the statement period %s to %s",
+				m.TransactionDate.Format("2006-01-02"), a.Header.StartDate.Format("2006-01-02"), a.Header.EndDate.Format("2006-01-02"))})
+		}
+	}
+	return findings
+}
+
+func lintLateValueDate(a *Account, lo *LintOptions) []*lintFinding {
+	findings := []*lintFinding{}
+	for i, m := range a.Movements {
+		if days := int(m.ValueDate.Sub(m.TransactionDate).Hours() / 24); days > *lo.MaxValueDateDelay {
+			findings = append(findings, &lintFinding{i, fmt.Sprintf("value date %s is %d days after the transaction date %s",
+				m.ValueDate.Format("2006-01-02"), days, m.TransactionDate.Format("2006-01-02"))})
+		}
+	}
+	return findings
+}
+
+func lintUnknownConcept(a *Account, lo *LintOptions) []*lintFinding {
+	findings := []*lintFinding{}
+	for i, m := range a.Movements {
+		if ConceptDescription(m.CommonConcept) == "" {
+			findings = append(findings, &lintFinding{i, "unknown common concept " + strconv.Quote(m.CommonConcept)})
+		}
+	}
+	return findings
+}
+
+func lintCurrencyMismatch(a *Account, lo *LintOptions) []*lintFinding {
+	if a.Header == nil || a.Footer == nil || a.Header.Currency == a.Footer.Currency {
+		return nil
+	}
+	return []*lintFinding{{-1, "footer currency " + a.Footer.Currency + " differs from header currency " + a.Header.Currency}}
+}
+
+func lintDuplicateMovement(a *Account, lo *LintOptions) []*lintFinding {
+	findings := []*lintFinding{}
+
+	first := map[string]int{}
+	for i, m := range a.Movements {
+		fingerprint := m.Fingerprint()
+		if j, ok := first[fingerprint]; ok {
+			findings = append(findings, &lintFinding{i, "duplicate of movement " + strconv.Itoa(j+1)})
+			continue
+		}
+		first[fingerprint] = i
+	}
+	return findings
+}
+
+func lintNonASCII(a *Account, lo *LintOptions) []*lintFinding {
+	findings := []*lintFinding{}
+	if a.Header != nil && !isASCII(a.Header.AccountName) {
+		findings = append(findings, &lintFinding{-1, "account name " + strconv.Quote(strings.TrimSpace(a.Header.AccountName)) + " has non ASCII characters"})
+	}
+	for i, m := range a.Movements {
+		texts := append([]string{m.DocumentNumber, m.Description}, m.ExtraInformation...)
+		for _, text := range texts {
+			if !isASCII(text) {
+				findings = append(findings, &lintFinding{i, strconv.Quote(strings.TrimSpace(text)) + " has non ASCII characters"})
+			}
+		}
+	}
+	return findings
+}
+
+func lintZeroAmount(a *Account, lo *LintOptions) []*lintFinding {
+	findings := []*lintFinding{}
+	for i, m := range a.Movements {
+		if roundAmount(m.Amount) == 0 {
+			findings = append(findings, &lintFinding{i, "zero amount"})
+		}
+	}
+	return findings
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
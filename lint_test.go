@@ -0,0 +1,117 @@
+package n43
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_lint(t *testing.T) {
+	if issues := parseSample(t).Lint(nil); len(issues) != 0 {
+		t.Errorf("Expected no issues in the sample, but %v found", issues)
+	}
+
+	in := parseSample(t)
+	a := in.Accounts[0]
+	a.Footer.Currency = "840"
+	a.Movements[0].TransactionDate = a.Header.StartDate.AddDate(0, 0, -1)
+	a.Movements[1].ValueDate = a.Movements[1].TransactionDate.AddDate(0, 0, 10)
+	a.Movements[2].CommonConcept = "55"
+	a.Movements[3].ExtraInformation[0] = "CAFÉ"
+	a.Movements[4].Amount = 0
+	duplicate := *a.Movements[4]
+	a.Movements = append(a.Movements, &duplicate)
+
+	issues := in.Lint(nil)
+
+	expected := []struct {
+		rule     string
+		movement int
+	}{
+		{LINT_CURRENCY_MISMATCH, -1},
+		{LINT_DATE_OUT_OF_PERIOD, 0},
+		{LINT_LATE_VALUE_DATE, 1},
+		{LINT_UNKNOWN_CONCEPT, 2},
+		{LINT_NON_ASCII, 3},
+		{LINT_ZERO_AMOUNT, 4},
+		{LINT_DUPLICATE_MOVEMENT, 5},
+		{LINT_ZERO_AMOUNT, 5},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, but %v found", len(expected), issues)
+	}
+	for i, e := range expected {
+		if issues[i].Rule != e.rule || issues[i].Movement != e.movement {
+			t.Errorf("Expected %s in movement %d, but %v found", e.rule, e.movement, issues[i])
+		}
+	}
+	if issues[0].Severity != LINT_ERROR || issues[0].Error() != "account 1: error: footer currency 840 differs from header currency 978 (currency-mismatch)" {
+		t.Errorf("Expected the currency mismatch error, but %q found", issues[0].Error())
+	}
+
+	opts, err := LoadLintOptions(strings.NewReader(`{"rules": {"zero-amount": false}, "severities": {"non-ascii": "error"}, "max_value_date_delay": 15}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues = in.Lint(opts)
+	if len(issues) != 5 {
+		t.Fatalf("Expected 5 issues, but %v found", issues)
+	}
+	for _, issue := range issues {
+		if issue.Rule == LINT_ZERO_AMOUNT || issue.Rule == LINT_LATE_VALUE_DATE {
+			t.Errorf("Expected %s to be disabled", issue.Rule)
+		}
+		if issue.Rule == LINT_NON_ASCII && issue.Severity != LINT_ERROR {
+			t.Errorf("Expected non-ascii to be an error, but %s found", issue.Severity)
+		}
+	}
+}
+
+func Test_loadLintOptions(t *testing.T) {
+	for _, config := range []string{
+		`{"rules": {"no-such-rule": false}}`,
+		`{"severities": {"zero-amount": "fatal"}}`,
+		`{"unknown": 1}`,
+		`{"max_value_date_delay": -1}`,
+	} {
+		if _, err := LoadLintOptions(strings.NewReader(config)); err == nil {
+			t.Errorf("Expected an error for %s", config)
+		}
+	}
+}
+
+func Test_lintValueDateDelay(t *testing.T) {
+	in := parseSample(t)
+
+	tests := []struct {
+		delay     int
+		movements []int
+	}{
+		{0, []int{0, 3}},
+		{2, []int{3}},
+		{3, []int{}},
+	}
+
+	for _, test := range tests {
+		delay := test.delay
+		issues := in.Lint(&LintOptions{MaxValueDateDelay: &delay})
+		if len(issues) != len(test.movements) {
+			t.Errorf("Expected %d late value dates with a delay of %d days, but %v found", len(test.movements), delay, issues)
+			continue
+		}
+		for i, issue := range issues {
+			if issue.Rule != LINT_LATE_VALUE_DATE || issue.Movement != test.movements[i] {
+				t.Errorf("Expected a late value date in movement %d, but %v found", test.movements[i], issue)
+			}
+		}
+	}
+}
+
+func Test_lintRules(t *testing.T) {
+	rules := LintRules()
+	rules[0].Severity = LINT_INFO
+	rules[1] = nil
+
+	if again := LintRules(); again[0].Severity != LINT_WARNING || again[1] == nil {
+		t.Errorf("Expected the rules to be copies, but %v found", again)
+	}
+}
@@ -0,0 +1,85 @@
+package n43
+
+import (
+	"sort"
+)
+
+// Merge combines the statements of the same account found in the documents
+// into a single account per IBAN, in order of appearance. The merged account
+// spans from the earliest StartDate to the latest EndDate and starts with the
+// initial balance of the earliest statement.
+//
+// Movements found in more than one statement, according to their
+// fingerprints, are kept once. Identical movements within a statement are
+// all kept, see Account.Fingerprints. The movements are sorted by
+// transaction date and their balances and the footers are recomputed, so
+// the result can be written back with a Writer. The documents are not
+// modified.
+func Merge(docs []*Norma43) *Norma43 {
+	statements := map[string][]*Account{}
+	order := []string{}
+	for _, doc := range docs {
+		for _, account := range doc.Accounts {
+			if account.Header == nil {
+				continue
+			}
+			iban := account.Header.IBAN()
+			if _, ok := statements[iban]; !ok {
+				order = append(order, iban)
+			}
+			statements[iban] = append(statements[iban], account)
+		}
+	}
+
+	n := new(Norma43)
+	for _, iban := range order {
+		account := mergeAccount(statements[iban])
+		n.Accounts = append(n.Accounts, account)
+
+		n.ReportedEntries += account.Records()
+	}
+
+	return n
+}
+
+func mergeAccount(statements []*Account) *Account {
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statements[i].Header.StartDate.Before(statements[j].Header.StartDate)
+	})
+
+	h := *statements[0].Header
+	account := &Account{Header: &h}
+
+	seen := map[string]bool{}
+	for _, statement := range statements {
+		if statement.Header.EndDate.After(h.EndDate) {
+			h.EndDate = statement.Header.EndDate
+		}
+
+		// The fingerprints of a statement are unique, see Fingerprints.
+		for i, fingerprint := range statement.Fingerprints() {
+			if seen[fingerprint] {
+				continue
+			}
+			seen[fingerprint] = true
+
+			m := *statement.Movements[i]
+			account.Movements = append(account.Movements, &m)
+		}
+	}
+
+	sort.SliceStable(account.Movements, func(i, j int) bool {
+		return account.Movements[i].TransactionDate.Before(account.Movements[j].TransactionDate)
+	})
+
+	balance := h.InitialBalance
+	for _, m := range account.Movements {
+		balance += m.Amount
+		m.Balance = roundAmount(balance)
+		m.FilteredSum = roundAmount(balance - h.InitialBalance)
+	}
+
+	account.Footer = account.ComputeFooter()
+
+	return account
+}
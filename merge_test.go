@@ -0,0 +1,64 @@
+package n43
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_merge(t *testing.T) {
+	first := parseSample(t)
+
+	// A resent statement repeating the last two movements of the first one
+	// and adding a new one, with the two identical insurance debits.
+	second := parseSample(t)
+	h := second.Accounts[0].Header
+	h.StartDate = h.StartDate.AddDate(0, 0, 2)
+	h.EndDate = h.EndDate.AddDate(0, 0, 5)
+	extra := &Movement{
+		TransactionDate: h.EndDate,
+		ValueDate:       h.EndDate,
+		CommonConcept:   CONCEPT_TRANSFERS,
+		OwnConcept:      "000",
+		Amount:          1000,
+		Description:     "PAYROLL",
+	}
+	second.Accounts[0].Movements = append(second.Accounts[0].Movements[1:], extra)
+
+	merged := Merge([]*Norma43{second, first})
+
+	if len(merged.Accounts) != 1 {
+		t.Fatalf("Expected 1 account, but %d found", len(merged.Accounts))
+	}
+
+	account := merged.Accounts[0]
+	if !account.Header.StartDate.Equal(first.Accounts[0].Header.StartDate) || !account.Header.EndDate.Equal(h.EndDate) {
+		t.Errorf("Expected the merged period to span both statements, but %s to %s found", account.Header.StartDate, account.Header.EndDate)
+	}
+	if len(account.Movements) != 6 {
+		t.Fatalf("Expected 6 movements, but %d found", len(account.Movements))
+	}
+	if account.Movements[5] == extra || account.Movements[5].Description != "PAYROLL" {
+		t.Errorf("Expected a copy of the new movement last, but %+v found", account.Movements[5])
+	}
+	if account.Movements[5].Balance != 3436.43 {
+		t.Errorf("Expected a recomputed balance of 3436.43, but %.2f found", account.Movements[5].Balance)
+	}
+	if account.Footer.CreditEntries != 2 || account.Footer.CreditAmount != 1138.57 || account.Footer.FinalBalance != 3436.43 {
+		t.Errorf("Expected a recomputed footer, but %+v found", account.Footer)
+	}
+	if first.Accounts[0].Footer.FinalBalance != 2301.59 {
+		t.Errorf("Expected the documents not to be modified")
+	}
+	if errs := merged.Validate(); len(errs) != 0 {
+		t.Errorf("Expected a valid merged document, but %v found", errs)
+	}
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf, nil).Write(merged); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 15 || !strings.HasSuffix(strings.TrimSpace(lines[14]), "000014") {
+		t.Errorf("Expected 14 records and the end of file record, but %q found", buf.String())
+	}
+}
@@ -0,0 +1,424 @@
+package n43
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MT940Writer writes a Norma43 document as SWIFT MT940 customer statement
+// messages, one message per account.
+type MT940Writer struct {
+	w *bufio.Writer
+}
+
+// MT940Reader reads SWIFT MT940 customer statement messages into a Norma43
+// document with one account per message.
+type MT940Reader struct {
+	r io.Reader
+}
+
+// mt940TransactionTypes maps the AEB common concept codes to the SWIFT
+// transaction type identification codes of credit and debit movements.
+var mt940TransactionTypes = map[string][2]string{
+	CONCEPT_CHECKS:        {"CHK", "CHK"},
+	CONCEPT_DIRECT_DEBITS: {"DDT", "DDT"},
+	CONCEPT_TRANSFERS:     {"TRF", "TRF"},
+	CONCEPT_LOANS:         {"LDP", "LDP"},
+	CONCEPT_REMITTANCES:   {"COL", "COL"},
+	CONCEPT_SUBSCRIPTIONS: {"SEC", "SEC"},
+	CONCEPT_DIVIDENDS:     {"DIV", "DIV"},
+	CONCEPT_SECURITIES:    {"SEC", "SEC"},
+	CONCEPT_FOREIGN:       {"FEX", "FEX"},
+	CONCEPT_RETURNS:       {"RTI", "RTI"},
+	CONCEPT_STAMP_DUTIES:  {"COM", "COM"},
+	CONCEPT_INTEREST_FEES: {"INT", "CHG"},
+}
+
+var (
+	mt940BalanceRe   = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+,\d*)$`)
+	mt940StatementRe = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)([NSF][A-Z0-9]{3})([^/]{0,16})(?://(.{0,16}))?$`)
+)
+
+func NewMT940Writer(w io.Writer) *MT940Writer {
+	return &MT940Writer{w: bufio.NewWriter(w)}
+}
+
+// MT940TransactionType returns the SWIFT transaction type identification
+// code of a movement, derived from its common concept code and the sign of
+// its amount.
+func MT940TransactionType(m *Movement) string {
+	idx := 0
+	if m.Amount < 0 {
+		idx = 1
+	}
+
+	if types, ok := mt940TransactionTypes[m.CommonConcept]; ok {
+		return types[idx]
+	}
+	return "MSC"
+}
+
+func (mt *MT940Writer) Write(n *Norma43) error {
+	for i, account := range n.Accounts {
+		h := account.Header
+		if h == nil {
+			h = new(Header)
+		}
+		ccy := CurrencyCode(h.Currency)
+
+		mt.tag("20", fmt.Sprintf("N43%s%03d", h.EndDate.Format("060102"), i+1))
+		mt.tag("25", h.IBAN())
+		mt.tag("28C", fmt.Sprintf("%05d/001", i+1))
+		mt.tag("60F", mt940Balance(h.InitialBalance, h.StartDate, ccy))
+
+		for _, m := range account.Movements {
+			mt.writeMovement(m)
+		}
+
+		mt.tag("62F", mt940Balance(accountFinalBalance(account), h.EndDate, ccy))
+		mt.w.WriteString("-\n")
+	}
+
+	return mt.w.Flush()
+}
+
+func (mt *MT940Writer) writeMovement(m *Movement) {
+	reference := mt940Text(strings.TrimSpace(m.DocumentNumber), 16)
+	if strings.Trim(reference, "0 ") == "" {
+		reference = "NONREF"
+	}
+
+	line := m.ValueDate.Format("060102") +
+		m.TransactionDate.Format("0102") +
+		mt940Indicator(m.Amount) +
+		mt940Amount(m.Amount) +
+		"N" + MT940TransactionType(m) +
+		reference
+
+	// The supplementary details are up to 34 characters.
+	if description := mt940Text(strings.TrimSpace(m.Description), 34); description != "" {
+		line += "\n" + description
+	}
+	mt.tag("61", line)
+
+	// The information to account owner is up to 6 lines of 65 characters.
+	concepts := m.ComplementaryConcepts()
+	if len(concepts) > 6 {
+		concepts = concepts[:6]
+	}
+	for i, concept := range concepts {
+		concepts[i] = mt940Text(concept, 65)
+	}
+	if len(concepts) > 0 {
+		mt.tag("86", strings.Join(concepts, "\n"))
+	}
+}
+
+// mt940Letters maps the letters out of the SWIFT X character set to their
+// closest ones in it.
+var mt940Letters = strings.NewReplacer(
+	"Á", "A", "À", "A", "Ä", "A", "Â", "A", "É", "E", "È", "E", "Ë", "E", "Ê", "E",
+	"Í", "I", "Ì", "I", "Ï", "I", "Î", "I", "Ó", "O", "Ò", "O", "Ö", "O", "Ô", "O",
+	"Ú", "U", "Ù", "U", "Ü", "U", "Û", "U", "Ñ", "N", "Ç", "C",
+	"á", "a", "à", "a", "ä", "a", "â", "a", "é", "e", "è", "e", "ë", "e", "ê", "e",
+	"í", "i", "ì", "i", "ï", "i", "î", "i", "ó", "o", "ò", "o", "ö", "o", "ô", "o",
+	"ú", "u", "ù", "u", "ü", "u", "û", "u", "ñ", "n", "ç", "c",
+	"ª", "a", "º", "o", "&", "+",
+)
+
+// mt940Text returns s in the SWIFT X character set, cut to size characters.
+// The characters out of the set become spaces, and a line starting with : or
+// - gets a leading space, as it would otherwise start a field or end the
+// message.
+func mt940Text(s string, size int) string {
+	text := []byte(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("/-?:().,'+ ", r):
+			return r
+		}
+		return ' '
+	}, mt940Letters.Replace(s)))
+
+	if len(text) > 0 && (text[0] == ':' || text[0] == '-') {
+		text = append([]byte{' '}, text...)
+	}
+	if len(text) > size {
+		text = text[:size]
+	}
+	return strings.TrimRight(string(text), " ")
+}
+
+func (mt *MT940Writer) tag(tag string, value string) {
+	mt.w.WriteString(":" + tag + ":" + value + "\n")
+}
+
+func mt940Balance(amount float64, date time.Time, ccy string) string {
+	return mt940Indicator(amount) + date.Format("060102") + ccy + mt940Amount(amount)
+}
+
+func mt940Indicator(amount float64) string {
+	if amount < 0 {
+		return "D"
+	}
+	return "C"
+}
+
+func mt940Amount(amount float64) string {
+	if amount < 0 {
+		amount = -amount
+	}
+	return strings.Replace(strconv.FormatFloat(amount, 'f', 2, 64), ".", ",", 1)
+}
+
+func NewMT940Reader(r io.Reader) *MT940Reader {
+	return &MT940Reader{r: r}
+}
+
+type mt940Field struct {
+	tag   string
+	value string
+}
+
+func (mt *MT940Reader) Parse() (*Norma43, error) {
+	n := new(Norma43)
+
+	messages, err := mt.messages()
+	if err != nil {
+		return n, err
+	}
+
+	for _, fields := range messages {
+		account, err := mt940Account(fields)
+		if err != nil {
+			return n, err
+		}
+		n.Accounts = append(n.Accounts, account)
+
+		n.ReportedEntries += account.Records()
+	}
+
+	return n, nil
+}
+
+// messages splits the input into the tagged fields of each message. The
+// SWIFT block headers and trailers around the text block are skipped.
+func (mt *MT940Reader) messages() ([][]mt940Field, error) {
+	messages := [][]mt940Field{}
+	var fields []mt940Field
+
+	scanner := bufio.NewScanner(mt.r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if idx := strings.Index(line, "{4:"); idx >= 0 {
+			line = line[idx+3:]
+		}
+
+		// Only a line with a single - ends the message, the text of the
+		// fields may start with one too.
+		switch {
+		case strings.TrimSpace(line) == "-" || strings.HasPrefix(line, "-}"):
+			if len(fields) > 0 {
+				messages = append(messages, fields)
+			}
+			fields = nil
+		case strings.HasPrefix(line, ":"):
+			end := strings.Index(line[1:], ":")
+			if end < 0 {
+				return messages, errors.New("malformed MT940 field " + line)
+			}
+
+			tag := line[1 : end+1]
+			if tag == "20" && len(fields) > 0 {
+				messages = append(messages, fields)
+				fields = nil
+			}
+			fields = append(fields, mt940Field{tag: tag, value: line[end+2:]})
+		case len(fields) > 0 && line != "":
+			fields[len(fields)-1].value += "\n" + line
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return messages, err
+	}
+
+	if len(fields) > 0 {
+		messages = append(messages, fields)
+	}
+	return messages, nil
+}
+
+func mt940Account(fields []mt940Field) (*Account, error) {
+	account := new(Account)
+
+	h := new(Header)
+	h.InformationModeCode = "3"
+	account.Header = h
+
+	var final *float64
+	var last *Movement
+
+	for _, f := range fields {
+		switch f.tag {
+		case "25":
+			h.BankCode, h.BranchCode, h.AccountNumber = SplitAccountID(strings.TrimSpace(f.value))
+		case "60F", "60M":
+			amount, date, ccy, err := mt940ParseBalance(f.value)
+			if err != nil {
+				return account, err
+			}
+			h.InitialBalance = amount
+			h.StartDate = date
+			h.Currency = CurrencyNumber(ccy)
+		case "62F", "62M":
+			amount, date, _, err := mt940ParseBalance(f.value)
+			if err != nil {
+				return account, err
+			}
+			final = &amount
+			h.EndDate = date
+		case "61":
+			m, err := mt940ParseMovement(f.value)
+			if err != nil {
+				return account, err
+			}
+			account.Movements = append(account.Movements, m)
+			last = m
+		case "86":
+			if last != nil {
+				last.ExtraInformation = extraInformation(strings.Split(f.value, "\n"))
+			}
+		}
+	}
+
+	balance := h.InitialBalance
+	for _, m := range account.Movements {
+		balance += m.Amount
+		m.Balance = balance
+		m.FilteredSum = balance - h.InitialBalance
+	}
+
+	account.Footer = account.ComputeFooter()
+	if final != nil {
+		account.Footer.FinalBalance = *final
+	}
+
+	return account, nil
+}
+
+func mt940ParseBalance(value string) (float64, time.Time, string, error) {
+	match := mt940BalanceRe.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, time.Time{}, "", errors.New("malformed MT940 balance " + value)
+	}
+
+	date, err := time.Parse("060102", match[2])
+	if err != nil {
+		return 0, date, "", err
+	}
+
+	amount, err := mt940ParseAmount(match[4], match[1])
+	return amount, date, match[3], err
+}
+
+func mt940ParseMovement(value string) (*Movement, error) {
+	var err error
+
+	m := new(Movement)
+
+	lines := strings.SplitN(value, "\n", 2)
+	match := mt940StatementRe.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if match == nil {
+		return m, errors.New("malformed MT940 statement line " + lines[0])
+	}
+
+	if m.ValueDate, err = time.Parse("060102", match[1]); err != nil {
+		return m, err
+	}
+
+	m.TransactionDate = m.ValueDate
+	if match[2] != "" {
+		if m.TransactionDate, err = time.Parse("20060102", m.ValueDate.Format("2006")+match[2]); err != nil {
+			return m, err
+		}
+		// The entry date has no year, take the closest to the value date.
+		if m.TransactionDate.Sub(m.ValueDate) > 183*24*time.Hour {
+			m.TransactionDate = m.TransactionDate.AddDate(-1, 0, 0)
+		} else if m.ValueDate.Sub(m.TransactionDate) > 183*24*time.Hour {
+			m.TransactionDate = m.TransactionDate.AddDate(1, 0, 0)
+		}
+	}
+
+	indicator := match[3]
+	// A reversal of a credit is a debit and the other way around.
+	switch indicator {
+	case "RC":
+		indicator = "D"
+	case "RD":
+		indicator = "C"
+	}
+
+	if m.Amount, err = mt940ParseAmount(match[5], indicator); err != nil {
+		return m, err
+	}
+
+	m.CommonConcept = mt940Concept(match[6][1:], m.Amount)
+	m.OwnConcept = "000"
+
+	if reference := strings.TrimSpace(match[7]); reference != "NONREF" {
+		m.DocumentNumber = reference
+	}
+
+	if len(lines) > 1 {
+		m.Description = strings.TrimSpace(lines[1])
+	}
+
+	return m, nil
+}
+
+func mt940ParseAmount(value string, indicator string) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil {
+		return 0, err
+	}
+	if indicator == "D" {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// mt940Concept returns the AEB common concept code of a SWIFT transaction
+// type identification code.
+func mt940Concept(code string, amount float64) string {
+	idx := 0
+	if amount < 0 {
+		idx = 1
+	}
+
+	for _, concept := range []string{
+		CONCEPT_CHECKS,
+		CONCEPT_DIRECT_DEBITS,
+		CONCEPT_TRANSFERS,
+		CONCEPT_LOANS,
+		CONCEPT_REMITTANCES,
+		CONCEPT_DIVIDENDS,
+		CONCEPT_SECURITIES,
+		CONCEPT_FOREIGN,
+		CONCEPT_RETURNS,
+		CONCEPT_STAMP_DUTIES,
+		CONCEPT_INTEREST_FEES,
+	} {
+		if mt940TransactionTypes[concept][idx] == code {
+			return concept
+		}
+	}
+	return CONCEPT_MISCELLANEOUS
+}
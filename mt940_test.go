@@ -0,0 +1,156 @@
+package n43
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_mt940(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewMT940Writer(&buf).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{
+		":25:ES4811112222033333444412\n",
+		":28C:00001/001\n",
+		":60F:C200203EUR2463,43\n",
+		":61:2002040203D23,99NMSCNONREF\n0000000000001234567890123456\n",
+		":86:COMPRA TARG 1234XXXXXXXX3456 SHOP TO B\nUY SEVERAL THINGS IN THERE.\n",
+		":62F:C200210EUR2301,59\n-\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in the MT940 output, but %q found", s, out)
+		}
+	}
+}
+
+func Test_mt940RoundTrip(t *testing.T) {
+	in := parseSample(t)
+
+	var buf bytes.Buffer
+	if err := NewMT940Writer(&buf).Write(in); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := NewMT940Reader(&buf).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out.Accounts) != 1 {
+		t.Fatalf("Expected 1 account, but %d found", len(out.Accounts))
+	}
+
+	h, e := out.Accounts[0].Header, in.Accounts[0].Header
+	if h.BankCode != e.BankCode || h.BranchCode != e.BranchCode || h.AccountNumber != e.AccountNumber || h.Currency != e.Currency {
+		t.Errorf("Expected account %+v, but %+v found", e, h)
+	}
+
+	if h.InitialBalance != e.InitialBalance || !h.StartDate.Equal(e.StartDate) || !h.EndDate.Equal(e.EndDate) {
+		t.Errorf("Expected balance %.2f from %s to %s, but %.2f from %s to %s found", e.InitialBalance, e.StartDate, e.EndDate, h.InitialBalance, h.StartDate, h.EndDate)
+	}
+
+	if out.Accounts[0].Footer.FinalBalance != in.Accounts[0].Footer.FinalBalance {
+		t.Errorf("Expected FinalBalance to be %.2f, but %.2f found", in.Accounts[0].Footer.FinalBalance, out.Accounts[0].Footer.FinalBalance)
+	}
+
+	if len(out.Accounts[0].Movements) != len(in.Accounts[0].Movements) {
+		t.Fatalf("Expected %d movements, but %d found", len(in.Accounts[0].Movements), len(out.Accounts[0].Movements))
+	}
+
+	for i, m := range out.Accounts[0].Movements {
+		e := in.Accounts[0].Movements[i]
+		if m.Amount != e.Amount || !m.TransactionDate.Equal(e.TransactionDate) || !m.ValueDate.Equal(e.ValueDate) {
+			t.Errorf("Expected movement %d to be %+v, but %+v found", i, e, m)
+		}
+		if fmt.Sprintf("%.2f", m.Balance) != fmt.Sprintf("%.2f", e.Balance) {
+			t.Errorf("Expected Balance %.2f in movement %d, but %.2f found", e.Balance, i, m.Balance)
+		}
+		if strings.Join(m.ExtraInformation, "|") != strings.Join(e.ExtraInformation, "|") {
+			t.Errorf("Expected extra information %q in movement %d, but %q found", e.ExtraInformation, i, m.ExtraInformation)
+		}
+		if m.Description != strings.TrimSpace(e.Description) {
+			t.Errorf("Expected description %q in movement %d, but %q found", e.Description, i, m.Description)
+		}
+	}
+
+	// And back to Norma43.
+	var n43 bytes.Buffer
+	if err := NewWriter(&n43, nil).Write(out); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := NewParser(strings.Split(n43.String(), "\n"), &ParserOptions{Trim: true}).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again.Accounts[0].Footer.FinalBalance != 2301.59 || again.Accounts[0].Header.InitialBalance != 2463.43 {
+		t.Errorf("Expected balances 2463.43 and 2301.59, but %+v and %+v found", again.Accounts[0].Header, again.Accounts[0].Footer)
+	}
+}
+
+func Test_mt940ReaderBank(t *testing.T) {
+	data := "{1:F01BANKESMMAXXX0000000000}{2:O9401200230101BANKESMMAXXX00000000002301011200N}{4:\r\n" +
+		":20:STMT230101\r\n" +
+		":25:21000418450200051332\r\n" +
+		":28C:1/1\r\n" +
+		":60F:D221230EUR10,00\r\n" +
+		":61:2301021230C110,5NTRFREF1234//BANKREF\r\n" +
+		"TRANSFER FROM ACME\r\n" +
+		":86:ACME INVOICE 42\r\n" +
+		"-50 PCT OFF\r\n" +
+		":62F:C230102EUR100,50\r\n" +
+		"-}"
+
+	out, err := NewMT940Reader(strings.NewReader(data)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account := out.Accounts[0]
+	if account.Header.AccountNumber != "0200051332" || account.Header.InitialBalance != -10 {
+		t.Errorf("Expected account 0200051332 with -10.00 balance, but %+v found", account.Header)
+	}
+
+	m := account.Movements[0]
+	if m.Amount != 110.5 || m.CommonConcept != CONCEPT_TRANSFERS || m.DocumentNumber != "REF1234" {
+		t.Errorf("Expected a 110.50 transfer REF1234, but %+v found", m)
+	}
+
+	if !m.TransactionDate.Equal(time.Date(2022, 12, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected entry date 2022-12-30, but %s found", m.TransactionDate)
+	}
+
+	if account.Footer.FinalBalance != 100.5 {
+		t.Errorf("Expected FinalBalance to be 100.50, but %.2f found", account.Footer.FinalBalance)
+	}
+
+	if len(out.Accounts) != 1 || len(m.ComplementaryConcepts()) != 2 || m.ComplementaryConcepts()[1] != "-50 PCT OFF" {
+		t.Errorf("Expected a line starting with - to continue the message, but %d accounts and %q found", len(out.Accounts), m.ComplementaryConcepts())
+	}
+}
+
+func Test_mt940Text(t *testing.T) {
+	in := parseSample(t)
+	m := in.Accounts[0].Movements[0]
+	m.Description = "CAFÉ 50% ÑANDÚ & CO 1234567890123456789"
+	m.ExtraInformation = extraInformation([]string{"-50 PCT OFF", ":20:RECIBO AÑO 2020 {ref}"})
+
+	var buf bytes.Buffer
+	if err := NewMT940Writer(&buf).Write(in); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{"\nCAFE 50  NANDU + CO 12345678901234\n", ":86: -50 PCT OFF\n", "\n :20:RECIBO ANO 2020  ref\n"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in the SWIFT character set, but %q found", s, out)
+		}
+	}
+}
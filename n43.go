@@ -11,48 +11,68 @@ import (
 )
 
 type Norma43 struct {
-	Accounts        []*Account
-	ReportedEntries int
+	Accounts        []*Account `json:"accounts"`
+	ReportedEntries int        `json:"reported_entries"`
 }
 
 type Account struct {
-	Header    *Header
-	Movements []*Movement
-	Footer    *Footer
+	Header    *Header     `json:"header"`
+	Movements []*Movement `json:"movements"`
+	Footer    *Footer     `json:"footer"`
 }
 
 type Header struct {
-	BankCode            string
-	BranchCode          string
-	AccountNumber       string
-	StartDate           time.Time
-	EndDate             time.Time
-	InitialBalance      float64
-	Currency            string
-	InformationModeCode string
-	AccountName         string
+	BankCode            string    `json:"bank_code"`
+	BranchCode          string    `json:"branch_code"`
+	AccountNumber       string    `json:"account_number"`
+	StartDate           time.Time `json:"start_date"`
+	EndDate             time.Time `json:"end_date"`
+	InitialBalance      float64   `json:"initial_balance"`
+	Currency            string    `json:"currency"`
+	InformationModeCode string    `json:"information_mode_code"`
+	AccountName         string    `json:"account_name"`
 }
 
+// Movement is a single entry (record 22) of an account together with its
+// extra information lines (records 23). Movements are kept in the same order
+// they appear in the file, even when the parser filters some of them out.
 type Movement struct {
-	BranchCode       string
-	TransactionDate  time.Time
-	ValueDate        time.Time
-	Amount           float64
-	Balance          float64
-	Description      string
-	ExtraInformation []string
+	BranchCode      string    `json:"branch_code"`
+	TransactionDate time.Time `json:"transaction_date"`
+	ValueDate       time.Time `json:"value_date"`
+	// CommonConcept is the two digit concept code shared by all the banks,
+	// see ConceptDescription.
+	CommonConcept string `json:"common_concept"`
+	// OwnConcept is the three digit concept code specific to each bank.
+	OwnConcept     string  `json:"own_concept"`
+	Amount         float64 `json:"amount"`
+	DocumentNumber string  `json:"document_number"`
+	// Balance is the bank running balance after this movement: the header
+	// InitialBalance plus every movement of the account up to and including
+	// this one, whether filtered out or not.
+	Balance float64 `json:"balance"`
+	// FilteredSum is the running sum of the amounts of the movements kept by
+	// the parser filters up to and including this one. Without filters it
+	// equals Balance minus the header InitialBalance.
+	FilteredSum      float64  `json:"filtered_sum"`
+	Description      string   `json:"description"`
+	ExtraInformation []string `json:"extra_information"`
+	// Category and Tags are set by a Categorizer, they are not part of the
+	// Norma43 records.
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
 }
 
 type Footer struct {
-	BankCode      string
-	BranchCode    string
-	AccountNumber string
-	DebitEntries  int
-	DebitAmount   float64
-	CreditEntries int
-	CreditAmount  float64
-	FinalBalance  float64
-	Currency      string
+	BankCode      string  `json:"bank_code"`
+	BranchCode    string  `json:"branch_code"`
+	AccountNumber string  `json:"account_number"`
+	DebitEntries  int     `json:"debit_entries"`
+	DebitAmount   float64 `json:"debit_amount"`
+	CreditEntries int     `json:"credit_entries"`
+	CreditAmount  float64 `json:"credit_amount"`
+	FinalBalance  float64 `json:"final_balance"`
+	Currency      string  `json:"currency"`
 }
 
 type (
@@ -94,6 +114,9 @@ type Parser struct {
 	pos         int
 	n43         *Norma43
 	parseOption *ParserOptions
+	balance     float64
+	filteredSum float64
+	err         error
 }
 
 type ParserOptions struct {
@@ -107,7 +130,20 @@ type ParserOptions struct {
 	filterLineOutRe *regexp.Regexp
 }
 
+// NewParser returns a parser of the lines. Invalid FilterLineIn or
+// FilterLineOut expressions are reported by Parse.
 func NewParser(lines []string, parserOptions *ParserOptions) *Parser {
+	po, err := newParserOptions(parserOptions)
+	return &Parser{
+		lines:       lines,
+		pos:         -1,
+		n43:         &Norma43{Accounts: []*Account{}},
+		parseOption: po,
+		err:         err,
+	}
+}
+
+func newParserOptions(parserOptions *ParserOptions) (*ParserOptions, error) {
 	po := new(ParserOptions)
 
 	po.TimeFormat = ENGLISH_DATE
@@ -118,19 +154,19 @@ func NewParser(lines []string, parserOptions *ParserOptions) *Parser {
 		po.FilterPositive = parserOptions.FilterPositive
 		po.FilterNegative = parserOptions.FilterNegative
 
+		var err error
 		if parserOptions.FilterLineIn != "" {
-			po.filterLineInRe = regexp.MustCompile(parserOptions.FilterLineIn)
+			if po.filterLineInRe, err = regexp.Compile(parserOptions.FilterLineIn); err != nil {
+				return po, errors.New("invalid line filter: " + err.Error())
+			}
 		}
 		if parserOptions.FilterLineOut != "" {
-			po.filterLineOutRe = regexp.MustCompile(parserOptions.FilterLineOut)
+			if po.filterLineOutRe, err = regexp.Compile(parserOptions.FilterLineOut); err != nil {
+				return po, errors.New("invalid line filter: " + err.Error())
+			}
 		}
 	}
-	return &Parser{
-		lines:       lines,
-		pos:         -1,
-		n43:         &Norma43{},
-		parseOption: po,
-	}
+	return po, nil
 }
 
 func NewParserReader(r io.Reader, parserOptions *ParserOptions) *Parser {
@@ -192,8 +228,11 @@ func extract_date(date string, format TimeFormat) (time.Time, error) {
 }
 
 func (p *Parser) lineType() (LineType, error) {
-	lineCode := p.getLine()[:2]
-	return getLineType(lineCode)
+	line := p.getLine()
+	if len(line) < 2 {
+		return LineType(0), errors.New("malformed line " + strconv.Itoa(p.pos+1))
+	}
+	return getLineType(line[:2])
 }
 
 func (p *Parser) getLine() string {
@@ -227,6 +266,16 @@ func (p *Parser) next() (LineType, error) {
 	return p.lineType()
 }
 
+// atEnd reports whether only blank lines are left.
+func (p *Parser) atEnd() bool {
+	for _, line := range p.lines[p.pos+1:] {
+		if strings.TrimSpace(line) != "" {
+			return false
+		}
+	}
+	return true
+}
+
 func (p *Parser) peek() (string, error) {
 	if p.pos+1 < len(p.lines) {
 		return p.lines[p.pos+1], nil
@@ -235,11 +284,24 @@ func (p *Parser) peek() (string, error) {
 }
 
 func (p *Parser) Parse() (*Norma43, error) {
+	if p.err != nil {
+		return p.n43, p.err
+	}
+
 	lineType, err := p.next()
 	if err != nil {
 		return p.n43, err
 	}
 
+	// Movements and footers need the account of a header.
+	switch lineType {
+	case HEADER_LINE:
+	case MOVEMENT_LINE, MOVEMENT_EXTRA_INFO_LINE:
+		return p.n43, errors.New("movement before account header")
+	default:
+		return p.n43, errors.New("record " + strconv.Itoa(int(lineType)) + " before account header")
+	}
+
 header:
 
 	if lineType == HEADER_LINE {
@@ -249,11 +311,13 @@ header:
 			return p.n43, err
 		}
 
-		p.n43.Accounts = make([]*Account, 0)
 		account := new(Account)
 		account.Header = h
 		p.n43.Accounts = append(p.n43.Accounts, account)
 
+		p.balance = h.InitialBalance
+		p.filteredSum = 0
+
 	}
 
 	lineType, err = p.next()
@@ -269,109 +333,39 @@ header:
 				return p.n43, err
 			}
 
-			filtered := false
-
-			if p.parseOption.FilterNegative && l.Amount < 0 {
-				// filter
+			// Process extra info
+			for {
 				peekLine, err := p.peek()
 				if err != nil {
 					return p.n43, errors.New("malformed document")
 				}
 
-				peekLineType, err := getLineType(peekLine[:2])
-				if err != nil {
-					return p.n43, err
-				}
-
-				if peekLineType == MOVEMENT_EXTRA_INFO_LINE {
-					p.next()
-				}
-
-				filtered = true
-			}
-
-			if p.parseOption.FilterPositive && l.Amount > 0 {
-				// filter
-				peekLine, err := p.peek()
-				if err != nil {
+				if len(peekLine) < 2 {
 					return p.n43, errors.New("malformed document")
 				}
-
 				peekLineType, err := getLineType(peekLine[:2])
 				if err != nil {
 					return p.n43, err
 				}
 
-				if peekLineType == MOVEMENT_EXTRA_INFO_LINE {
-					p.next()
-				}
-
-				filtered = true
-			}
-
-			if !filtered {
-				p.n43.Accounts[len(p.n43.Accounts)-1].Movements = append(p.n43.Accounts[len(p.n43.Accounts)-1].Movements, l)
-			}
-		} else if lineType == MOVEMENT_EXTRA_INFO_LINE {
-			// Process extra info
-
-		extraInfoLine:
-			line := p.getLine()
-
-			if p.parseOption.filterLineInRe != nil {
-				res := p.parseOption.filterLineInRe.FindString(line[4:])
-				if res != "" { // match
-					if p.parseOption.filterLineOutRe != nil {
-						res = p.parseOption.filterLineOutRe.FindString(line[4:])
-						if res != "" {
-							// DO NOT INCLUDE
-							p.purgeLastMovemnt()
-						} else {
-							// INCLUDE
-							p.parseMovementLineExtraInfo()
-						}
-					} else {
-						// INCLUDE
-						p.parseMovementLineExtraInfo()
-					}
-				} else {
-					// DO NOT INCLUDE
-					p.purgeLastMovemnt()
-				}
-			}
-
-			if p.parseOption.filterLineOutRe != nil {
-				res := p.parseOption.filterLineOutRe.FindString(line[4:])
-				if res != "" {
-					// DO NOT INCLUDE
-					p.purgeLastMovemnt()
-				} else {
-					// INCLUDE
-					p.parseMovementLineExtraInfo()
+				if peekLineType != MOVEMENT_EXTRA_INFO_LINE {
+					break
 				}
-			}
-
-			if p.parseOption.filterLineInRe == nil && p.parseOption.filterLineOutRe == nil {
-				p.parseMovementLineExtraInfo()
-			}
-
-			peekLine, err := p.peek()
-			if err != nil {
-				return p.n43, errors.New("malformed document")
-			}
-			peekLineType, err := getLineType(peekLine[:2])
-			if err != nil {
-				return p.n43, err
-			}
 
-			if peekLineType == MOVEMENT_EXTRA_INFO_LINE {
 				_, err = p.next()
 				if err != nil {
 					return p.n43, err
 				}
-				goto extraInfoLine
+				if err := p.parseMovementLineExtraInfo(l); err != nil {
+					return p.n43, err
+				}
 			}
 
+			if p.parseOption.keepMovement(l) {
+				p.filteredSum += l.Amount
+				l.FilteredSum = p.filteredSum
+				p.n43.Accounts[len(p.n43.Accounts)-1].Movements = append(p.n43.Accounts[len(p.n43.Accounts)-1].Movements, l)
+			}
 		}
 
 		lineType, err = p.next()
@@ -389,6 +383,16 @@ header:
 		p.n43.Accounts[len(p.n43.Accounts)-1].Footer = f
 	}
 
+	// Some files end right after the last footer, without end of file record.
+	if p.atEnd() {
+		return p.n43, nil
+	}
+
+	lineType, err = p.next()
+	if err != nil {
+		return p.n43, err
+	}
+
 	if lineType == HEADER_LINE {
 		goto header
 	}
@@ -396,7 +400,10 @@ header:
 	if lineType == END_OF_FILE_LINE {
 		// Process EOF
 		line := p.getLine()
-		reportedEntities, err := strconv.Atoi(line[20:])
+		if len(line) < 26 {
+			return p.n43, errors.New("malformed end of file record")
+		}
+		reportedEntities, err := strconv.Atoi(strings.TrimSpace(line[20:]))
 		if err != nil {
 			return p.n43, err
 		}
@@ -411,6 +418,9 @@ func (p *Parser) parseHeader() (*Header, error) {
 	var err error
 
 	line := p.getLine()
+	if len(line) < 51 {
+		return h, errors.New("malformed header record")
+	}
 
 	h.BankCode = line[2:6]
 	h.BranchCode = line[6:10]
@@ -445,6 +455,13 @@ func (p *Parser) parseMovementLine() (*Movement, error) {
 	var err error
 
 	line := p.getLine()
+	if len(line) < 42 {
+		return m, errors.New("malformed movement record")
+	}
+	// The document number and the references may be blank and trimmed.
+	if len(line) < 52 {
+		line += strings.Repeat(" ", 52-len(line))
+	}
 
 	m.BranchCode = line[6:10]
 	m.TransactionDate, err = extract_date(line[10:16], ENGLISH_DATE)
@@ -455,6 +472,8 @@ func (p *Parser) parseMovementLine() (*Movement, error) {
 	if err != nil {
 		return m, err
 	}
+	m.CommonConcept = line[22:24]
+	m.OwnConcept = line[24:27]
 	amountSign := float64(1)
 	if line[27:28] != "2" {
 		amountSign = -1
@@ -464,18 +483,11 @@ func (p *Parser) parseMovementLine() (*Movement, error) {
 		return m, err
 	}
 	m.Amount = amountSign * amount / 100
+	m.DocumentNumber = line[42:52]
 	m.Description = line[52:]
 
-	if len(p.n43.Accounts[len(p.n43.Accounts)-1].Movements) == 0 {
-		if p.n43.Accounts[len(p.n43.Accounts)-1].Header.InitialBalance == 0 {
-			m.Balance = float64(0)
-		} else {
-			m.Balance = p.n43.Accounts[len(p.n43.Accounts)-1].Header.InitialBalance
-		}
-	} else {
-		m.Balance = p.n43.Accounts[len(p.n43.Accounts)-1].Movements[len(p.n43.Accounts[len(p.n43.Accounts)-1].Movements)-1].Balance
-	}
-	m.Balance = m.Balance + m.Amount
+	p.balance += m.Amount
+	m.Balance = p.balance
 
 	return m, nil
 }
@@ -484,6 +496,9 @@ func (p *Parser) parseFooter() (*Footer, error) {
 	f := new(Footer)
 
 	line := p.getLine()
+	if len(line) < 76 {
+		return f, errors.New("malformed footer record")
+	}
 
 	f.BankCode = line[2:6]
 	f.BranchCode = line[6:10]
@@ -507,7 +522,7 @@ func (p *Parser) parseFooter() (*Footer, error) {
 	if err != nil {
 		return f, err
 	}
-	f.CreditAmount = creditAmount
+	f.CreditAmount = creditAmount / 100
 	finalBalanceSign := float64(1)
 	if line[58:59] != "2" {
 		finalBalanceSign = -1
@@ -522,23 +537,74 @@ func (p *Parser) parseFooter() (*Footer, error) {
 	return f, nil
 }
 
-func (p *Parser) parseMovementLineExtraInfo() {
+func (p *Parser) parseMovementLineExtraInfo(m *Movement) error {
 	line := p.getLine()
+	if len(line) < 4 {
+		return errors.New("malformed extra information record")
+	}
 
-	lastAccount := len(p.n43.Accounts) - 1
-	lastMovement := len(p.n43.Accounts[lastAccount].Movements) - 1
+	m.ExtraInformation = append(m.ExtraInformation, line[4:])
+	return nil
+}
+
+// Filter drops the movements not passing the filters of parserOptions and
+// recomputes the filtered sums of the rest. The parser already filters the
+// movements it reads, Filter is meant for documents read from other formats
+// like camt.053 or MT940. Footers are left as they are.
+func (n *Norma43) Filter(parserOptions *ParserOptions) error {
+	po, err := newParserOptions(parserOptions)
+	if err != nil {
+		return err
+	}
 
-	p.n43.Accounts[lastAccount].Movements[lastMovement].ExtraInformation = append(p.n43.Accounts[lastAccount].Movements[lastMovement].ExtraInformation, line[4:])
+	for _, account := range n.Accounts {
+		movements := make([]*Movement, 0, len(account.Movements))
+		filteredSum := 0.0
+		for _, m := range account.Movements {
+			if po.keepMovement(m) {
+				filteredSum += m.Amount
+				m.FilteredSum = filteredSum
+				movements = append(movements, m)
+			}
+		}
+		account.Movements = movements
+	}
+	return nil
 }
 
-func (p *Parser) purgeLastMovemnt() {
-	lastAccount := len(p.n43.Accounts) - 1
-	lastMovement := len(p.n43.Accounts[lastAccount].Movements) - 1
+// keepMovement reports whether m passes the parser filters. The line filters
+// are only applied to movements carrying extra information lines: a movement
+// is kept when any of its lines matches FilterLineIn and none of them matches
+// FilterLineOut.
+func (po *ParserOptions) keepMovement(m *Movement) bool {
+	if po.FilterNegative && m.Amount < 0 {
+		return false
+	}
+
+	if po.FilterPositive && m.Amount > 0 {
+		return false
+	}
+
+	if len(m.ExtraInformation) == 0 {
+		return true
+	}
+
+	if po.filterLineInRe != nil && !matchAny(po.filterLineInRe, m.ExtraInformation) {
+		return false
+	}
+
+	if po.filterLineOutRe != nil && matchAny(po.filterLineOutRe, m.ExtraInformation) {
+		return false
+	}
 
-	p.n43.Accounts[lastAccount].Movements = remove(p.n43.Accounts[lastAccount].Movements, lastMovement)
+	return true
 }
 
-func remove[T any](s []T, i int) []T {
-	s[i] = s[len(s)-1]
-	return s[:len(s)-1]
+func matchAny(re *regexp.Regexp, lines []string) bool {
+	for _, line := range lines {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
 }
@@ -1,11 +1,38 @@
 package n43
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 )
 
+const sampleData = `111111222233334444122002032002102000000002463439783ACCOUNT NAME ************
+22    22222002032002041240810000000000239900000000000000000000001234567890123456
+2301COMPRA TARG 1234XXXXXXXX3456 SHOP TO BUY SEVERAL THINGS IN THERE.
+22    2222200203200203032041000000000070290000000000AHSOWMSOWI8765SJWISU76WU
+2301INSURANCE COMPANY ABC DEF GHI JKL MNO PQRS TUVWXYZ
+22    2222200203200203032041000000000070290000000000A224E4ERF000000000123FFF
+2301INSURANCE COMPANY ABC DEF GHI JKL MNO PQRS TUVWXYZ
+22    22222002032002061240820000000001385700000000000000000000001234567890123456
+2301CREDIT CARD 1234567890123456 1234 .SUPERMARKET WHATEVER NAME INC.
+22    22222002032002031240810000000000010000000000000000000000001234567890123456
+2301CREDIT CARD 1234567890123456 1234 .CAR GARAGE REPAIR.
+2302CREDIT CARD 1234567890123456 1234 .CAR GARAGE REPAIR EXTRA.
+3311112222333344441200015000000000661840000100000000050000200000000230159978
+88999999999999999999000034`
+
+func parseSample(t *testing.T) *Norma43 {
+	t.Helper()
+
+	parser := NewParser(strings.Split(sampleData, "\n"), &ParserOptions{Trim: true, TimeFormat: ENGLISH_DATE})
+	out, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
 func Test_n43(t *testing.T) {
 	data := `111111222233334444122002032002102000000002463439783ACCOUNT NAME ************
 22    22222002032002041240810000000000239900000000000000000000001234567890123456
@@ -136,8 +163,8 @@ func Test_n43(t *testing.T) {
 		t.Errorf("Expected CreditEntries to be 1, but %d found", out.Accounts[0].Footer.CreditEntries)
 	}
 
-	if out.Accounts[0].Footer.CreditAmount != 50000.000000 {
-		t.Errorf("Expected CreditAmount to be 50000.000000, but %f found", out.Accounts[0].Footer.CreditAmount)
+	if out.Accounts[0].Footer.CreditAmount != 500.00 {
+		t.Errorf("Expected CreditAmount to be 500.00, but %f found", out.Accounts[0].Footer.CreditAmount)
 	}
 
 	if out.Accounts[0].Footer.FinalBalance != 2301.59 {
@@ -148,3 +175,167 @@ func Test_n43(t *testing.T) {
 		t.Errorf("Expected Currency to be 978, but %s found", out.Accounts[0].Footer.Currency)
 	}
 }
+
+func Test_n43Filter(t *testing.T) {
+	ops := new(ParserOptions)
+	ops.Trim = true
+	ops.TimeFormat = ENGLISH_DATE
+	ops.FilterLineOut = "INSURANCE"
+
+	parser := NewParser(strings.Split(sampleData, "\n"), ops)
+	out, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	movements := out.Accounts[0].Movements
+	if len(movements) != 3 {
+		t.Fatalf("Expected 3 line movements, but %d found", len(movements))
+	}
+
+	expected := []struct {
+		amount      float64
+		balance     float64
+		filteredSum float64
+	}{
+		{-23.99, 2439.44, -23.99},
+		{138.57, 2437.43, 114.58},
+		{-1, 2436.43, 113.58},
+	}
+
+	for i, e := range expected {
+		m := movements[i]
+		if m.Amount != e.amount {
+			t.Errorf("Expected Amount in movement %d to be %.2f, but %.2f found", i, e.amount, m.Amount)
+		}
+		if fmt.Sprintf("%.2f", m.Balance) != fmt.Sprintf("%.2f", e.balance) {
+			t.Errorf("Expected Balance in movement %d to be %.2f, but %.2f found", i, e.balance, m.Balance)
+		}
+		if fmt.Sprintf("%.2f", m.FilteredSum) != fmt.Sprintf("%.2f", e.filteredSum) {
+			t.Errorf("Expected FilteredSum in movement %d to be %.2f, but %.2f found", i, e.filteredSum, m.FilteredSum)
+		}
+	}
+
+	if len(movements[2].ExtraInformation) != 2 {
+		t.Errorf("Expected 2 extra information lines in last movement, but %d found", len(movements[2].ExtraInformation))
+	}
+}
+
+func Test_n43FilterDocument(t *testing.T) {
+	out := parseSample(t)
+	if err := out.Filter(&ParserOptions{FilterLineOut: "INSURANCE", FilterPositive: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	movements := out.Accounts[0].Movements
+	if len(movements) != 2 {
+		t.Fatalf("Expected 2 line movements, but %d found", len(movements))
+	}
+	if movements[0].Amount != -23.99 || movements[1].Amount != -1 || fmt.Sprintf("%.2f", movements[1].FilteredSum) != "-24.99" {
+		t.Errorf("Expected the -23.99 and -1 movements with a filtered sum of -24.99, but %+v and %+v found", movements[0], movements[1])
+	}
+	if out.Accounts[0].Footer.DebitEntries != 15 {
+		t.Errorf("Expected the footer to be left as it is, but %+v found", out.Accounts[0].Footer)
+	}
+}
+
+func Test_n43InvalidFilter(t *testing.T) {
+	ops := &ParserOptions{FilterLineIn: "("}
+
+	if _, err := NewParser(strings.Split(sampleData, "\n"), ops).Parse(); err == nil || !strings.HasPrefix(err.Error(), "invalid line filter") {
+		t.Errorf("Expected an invalid line filter error, but %v found", err)
+	}
+	if err := parseSample(t).Filter(&ParserOptions{FilterLineOut: "["}); err == nil {
+		t.Errorf("Expected an invalid line filter error, but nothing found")
+	}
+}
+
+func Test_n43TruncatedRecords(t *testing.T) {
+	lines := strings.Split(sampleData, "\n")
+
+	tests := []struct {
+		line     int
+		size     int
+		expected string
+	}{
+		{0, 40, "malformed header record"},
+		{1, 30, "malformed movement record"},
+		{2, 3, "malformed extra information record"},
+		{12, 60, "malformed footer record"},
+		{13, 5, "malformed end of file record"},
+	}
+
+	for _, test := range tests {
+		truncated := append([]string{}, lines...)
+		truncated[test.line] = truncated[test.line][:test.size]
+
+		_, err := NewParser(truncated, &ParserOptions{Trim: true}).Parse()
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("Expected %q with record %s truncated, but %v found", test.expected, lines[test.line][:2], err)
+		}
+	}
+
+	for _, first := range []int{1, 2} {
+		_, err := NewParser(lines[first:], &ParserOptions{Trim: true}).Parse()
+		if err == nil || err.Error() != "movement before account header" {
+			t.Errorf("Expected an error with record %s before the header, but %v found", lines[first][:2], err)
+		}
+	}
+	if _, err := NewParser(lines[12:], &ParserOptions{Trim: true}).Parse(); err == nil || err.Error() != "record 33 before account header" {
+		t.Errorf("Expected an error with a footer before the header, but %v found", err)
+	}
+
+	// Blank document numbers and references are trimmed with the line.
+	blank := append([]string{}, lines...)
+	blank[1] = blank[1][:42] + strings.Repeat(" ", 38)
+	out, err := NewParser(blank, &ParserOptions{Trim: true}).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := out.Accounts[0].Movements[0]; m.Amount != -23.99 || strings.TrimSpace(m.DocumentNumber) != "" {
+		t.Errorf("Expected a movement without document number, but %+v found", m)
+	}
+}
+
+func Test_n43MultipleAccounts(t *testing.T) {
+	data := `111111222233334444122002032002102000000002463439783ACCOUNT NAME ************
+22    22222002032002041240810000000000239900000000000000000000001234567890123456
+2301COMPRA TARG 1234XXXXXXXX3456 SHOP TO BUY SEVERAL THINGS IN THERE.
+331111222233334444120000100000000000239900000000000000000000200000000002439449780000
+111111222255556666772002032002102000000000100000978ANOTHER ACCOUNT
+22    22222002032002041240820000000000050000000000000000000000001234567890123456
+331111222255556666770000000000000000000000000010000000000050020000000000150000978
+88999999999999999999000006
+
+`
+
+	out, err := NewParser(strings.Split(data, "\n"), &ParserOptions{Trim: true, TimeFormat: ENGLISH_DATE}).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out.Accounts) != 2 {
+		t.Fatalf("Expected 2 accounts, but %d found", len(out.Accounts))
+	}
+	if out.Accounts[0].Header.AccountNumber != "3333444412" || len(out.Accounts[0].Movements) != 1 || out.Accounts[0].Footer == nil {
+		t.Errorf("Expected the first account, but %+v found", out.Accounts[0])
+	}
+	if out.Accounts[1].Header.AccountNumber != "5555666677" || len(out.Accounts[1].Movements) != 1 || out.Accounts[1].Footer == nil {
+		t.Errorf("Expected the second account, but %+v found", out.Accounts[1])
+	}
+	if out.Accounts[1].Movements[0].Balance != 105 {
+		t.Errorf("Expected the balance of the second account to start again, but %.2f found", out.Accounts[1].Movements[0].Balance)
+	}
+	if out.ReportedEntries != 6 {
+		t.Errorf("Expected 6 reported entries, but %d found", out.ReportedEntries)
+	}
+
+	lines := strings.Split(data, "\n")
+	out, err = NewParser(lines[:7], &ParserOptions{Trim: true, TimeFormat: ENGLISH_DATE}).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Accounts) != 2 || out.ReportedEntries != 0 {
+		t.Errorf("Expected 2 accounts without end of file record, but %+v found", out)
+	}
+}
@@ -0,0 +1,261 @@
+package n43
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type OFXVersion int
+
+const (
+	// OFX_V1 is the SGML based OFX 1.0.2.
+	OFX_V1 OFXVersion = 102
+	// OFX_V2 is the XML based OFX 2.2.0.
+	OFX_V2 OFXVersion = 220
+)
+
+// OFXWriter writes a Norma43 document as an OFX bank statement response with
+// one statement per account.
+type OFXWriter struct {
+	w         *bufio.Writer
+	ofxOption *OFXOptions
+}
+
+type OFXOptions struct {
+	Version OFXVersion
+	// AccountType is the OFX ACCTTYPE of the accounts, CHECKING by default.
+	AccountType string
+	// Language is the OFX LANGUAGE of the response, SPA by default.
+	Language string
+}
+
+// ofxTransactionTypes maps the AEB common concept codes to the OFX TRNTYPE
+// of credit and debit movements.
+var ofxTransactionTypes = map[string][2]string{
+	CONCEPT_CHECKS:        {"CREDIT", "CHECK"},
+	CONCEPT_DEPOSITS:      {"DEP", "DEBIT"},
+	CONCEPT_DIRECT_DEBITS: {"CREDIT", "DIRECTDEBIT"},
+	CONCEPT_TRANSFERS:     {"XFER", "XFER"},
+	CONCEPT_LOANS:         {"CREDIT", "PAYMENT"},
+	CONCEPT_DIVIDENDS:     {"DIV", "DEBIT"},
+	CONCEPT_ATM:           {"ATM", "ATM"},
+	CONCEPT_CARDS:         {"POS", "POS"},
+	CONCEPT_PAYROLL:       {"DIRECTDEP", "DEBIT"},
+	CONCEPT_STAMP_DUTIES:  {"CREDIT", "SRVCHG"},
+	CONCEPT_INTEREST_FEES: {"INT", "FEE"},
+}
+
+func NewOFXWriter(w io.Writer, ofxOptions *OFXOptions) *OFXWriter {
+	oo := new(OFXOptions)
+
+	oo.Version = OFX_V2
+	oo.AccountType = "CHECKING"
+	oo.Language = "SPA"
+
+	if ofxOptions != nil {
+		if ofxOptions.Version != 0 {
+			oo.Version = ofxOptions.Version
+		}
+		if ofxOptions.AccountType != "" {
+			oo.AccountType = ofxOptions.AccountType
+		}
+		if ofxOptions.Language != "" {
+			oo.Language = ofxOptions.Language
+		}
+	}
+
+	return &OFXWriter{
+		w:         bufio.NewWriter(w),
+		ofxOption: oo,
+	}
+}
+
+// OFXTransactionType returns the OFX TRNTYPE of a movement, derived from its
+// common concept code and the sign of its amount.
+func OFXTransactionType(m *Movement) string {
+	idx := 0
+	if m.Amount < 0 {
+		idx = 1
+	}
+
+	if types, ok := ofxTransactionTypes[m.CommonConcept]; ok {
+		return types[idx]
+	}
+
+	return [2]string{"CREDIT", "DEBIT"}[idx]
+}
+
+func (o *OFXWriter) Write(n *Norma43) error {
+	if o.ofxOption.Version != OFX_V1 && o.ofxOption.Version != OFX_V2 {
+		return errors.New("unsupported OFX version " + strconv.Itoa(int(o.ofxOption.Version)))
+	}
+
+	o.writeHeader()
+
+	now := time.Now().UTC()
+	if len(n.Accounts) > 0 && n.Accounts[0].Header != nil {
+		now = n.Accounts[0].Header.EndDate
+	}
+
+	o.open("OFX")
+	o.open("SIGNONMSGSRSV1")
+	o.open("SONRS")
+	o.status()
+	o.leaf("DTSERVER", ofxDate(now))
+	o.leaf("LANGUAGE", o.ofxOption.Language)
+	o.close("SONRS")
+	o.close("SIGNONMSGSRSV1")
+
+	o.open("BANKMSGSRSV1")
+	for i, account := range n.Accounts {
+		o.writeStatement(i+1, account)
+	}
+	o.close("BANKMSGSRSV1")
+	o.close("OFX")
+
+	return o.w.Flush()
+}
+
+func (o *OFXWriter) writeHeader() {
+	if o.ofxOption.Version == OFX_V1 {
+		fmt.Fprintf(o.w, "OFXHEADER:100\nDATA:OFXSGML\nVERSION:%d\nSECURITY:NONE\nENCODING:USASCII\nCHARSET:1252\nCOMPRESSION:NONE\nOLDFILEUID:NONE\nNEWFILEUID:NONE\n\n", o.ofxOption.Version)
+		return
+	}
+
+	o.w.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n")
+	fmt.Fprintf(o.w, "<?OFX OFXHEADER=\"200\" VERSION=\"%d\" SECURITY=\"NONE\" OLDFILEUID=\"NONE\" NEWFILEUID=\"NONE\"?>\n", o.ofxOption.Version)
+}
+
+func (o *OFXWriter) writeStatement(uid int, account *Account) {
+	h := account.Header
+	if h == nil {
+		h = new(Header)
+	}
+
+	o.open("STMTTRNRS")
+	o.leaf("TRNUID", strconv.Itoa(uid))
+	o.status()
+
+	o.open("STMTRS")
+	o.leaf("CURDEF", CurrencyCode(h.Currency))
+	o.open("BANKACCTFROM")
+	o.leaf("BANKID", h.BankCode)
+	o.leaf("BRANCHID", h.BranchCode)
+	o.leaf("ACCTID", h.AccountNumber)
+	o.leaf("ACCTTYPE", o.ofxOption.AccountType)
+	o.close("BANKACCTFROM")
+
+	o.open("BANKTRANLIST")
+	o.leaf("DTSTART", ofxDate(h.StartDate))
+	o.leaf("DTEND", ofxDate(h.EndDate))
+
+	fitids := account.Fingerprints()
+	for i, m := range account.Movements {
+		o.open("STMTTRN")
+		o.leaf("TRNTYPE", OFXTransactionType(m))
+		o.leaf("DTPOSTED", ofxDate(m.TransactionDate))
+		o.leaf("DTAVAIL", ofxDate(m.ValueDate))
+		o.leaf("TRNAMT", strconv.FormatFloat(m.Amount, 'f', 2, 64))
+		o.leaf("FITID", fitids[i])
+		if doc := strings.TrimSpace(m.DocumentNumber); doc != "" && strings.Trim(doc, "0") != "" {
+			o.leaf("REFNUM", doc)
+		}
+		if name := ofxName(m); name != "" {
+			o.leaf("NAME", name)
+		}
+		if memo := ofxMemo(m); memo != "" {
+			o.leaf("MEMO", memo)
+		}
+		o.close("STMTTRN")
+	}
+	o.close("BANKTRANLIST")
+
+	o.open("LEDGERBAL")
+	o.leaf("BALAMT", strconv.FormatFloat(accountFinalBalance(account), 'f', 2, 64))
+	o.leaf("DTASOF", ofxDate(h.EndDate))
+	o.close("LEDGERBAL")
+
+	o.close("STMTRS")
+	o.close("STMTTRNRS")
+}
+
+func (o *OFXWriter) status() {
+	o.open("STATUS")
+	o.leaf("CODE", "0")
+	o.leaf("SEVERITY", "INFO")
+	o.close("STATUS")
+}
+
+func (o *OFXWriter) open(tag string) {
+	fmt.Fprintf(o.w, "<%s>\n", tag)
+}
+
+func (o *OFXWriter) close(tag string) {
+	fmt.Fprintf(o.w, "</%s>\n", tag)
+}
+
+// leaf writes an element holding a value. OFX 1.x elements are SGML and
+// leave the value elements unclosed.
+func (o *OFXWriter) leaf(tag string, value string) {
+	if o.ofxOption.Version == OFX_V1 {
+		fmt.Fprintf(o.w, "<%s>%s\n", tag, ofxEscape(value))
+		return
+	}
+	fmt.Fprintf(o.w, "<%s>%s</%s>\n", tag, ofxEscape(value), tag)
+}
+
+var ofxReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func ofxEscape(s string) string {
+	return ofxReplacer.Replace(s)
+}
+
+func ofxDate(t time.Time) string {
+	return t.Format("20060102")
+}
+
+// ofxName returns the payee of a movement, the first extra information line
+// or the references, truncated to the 32 characters allowed by OFX.
+func ofxName(m *Movement) string {
+	name := strings.TrimSpace(m.Description)
+	if len(m.ExtraInformation) > 0 {
+		name = strings.TrimSpace(m.ExtraInformation[0])
+	}
+	return truncate(name, 32)
+}
+
+func ofxMemo(m *Movement) string {
+	lines := make([]string, 0, len(m.ExtraInformation))
+	for _, line := range m.ExtraInformation {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	return truncate(strings.Join(lines, " "), 255)
+}
+
+func truncate(s string, size int) string {
+	r := []rune(s)
+	if len(r) > size {
+		return string(r[:size])
+	}
+	return s
+}
+
+// accountFinalBalance returns the final balance of the account as reported
+// by its footer, falling back to the balance after the last movement.
+func accountFinalBalance(account *Account) float64 {
+	if account.Footer != nil {
+		return account.Footer.FinalBalance
+	}
+	if len(account.Movements) > 0 {
+		return account.Movements[len(account.Movements)-1].Balance
+	}
+	if account.Header != nil {
+		return account.Header.InitialBalance
+	}
+	return 0
+}
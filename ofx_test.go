@@ -0,0 +1,109 @@
+package n43
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func Test_ofx(t *testing.T) {
+	out := parseSample(t)
+
+	var first, second bytes.Buffer
+	if err := NewOFXWriter(&first, nil).Write(out); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewOFXWriter(&second, nil).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if first.String() != second.String() {
+		t.Errorf("Expected the same OFX output for the same document")
+	}
+
+	dec := xml.NewDecoder(strings.NewReader(first.String()))
+	fitids := map[string]bool{}
+	trntypes := []string{}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected well formed OFX 2 output, but %s", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			switch se.Name.Local {
+			case "FITID":
+				var value string
+				dec.DecodeElement(&value, &se)
+				fitids[value] = true
+			case "TRNTYPE":
+				var value string
+				dec.DecodeElement(&value, &se)
+				trntypes = append(trntypes, value)
+			}
+		}
+	}
+
+	if len(fitids) != 5 {
+		t.Errorf("Expected 5 unique FITID, but %d found", len(fitids))
+	}
+
+	expected := []string{"POS", "DIRECTDEBIT", "DIRECTDEBIT", "POS", "POS"}
+	if strings.Join(trntypes, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected TRNTYPE %v, but %v found", expected, trntypes)
+	}
+
+	for _, s := range []string{
+		"<CURDEF>EUR</CURDEF>",
+		"<BANKID>1111</BANKID>",
+		"<ACCTID>3333444412</ACCTID>",
+		"<DTSTART>20200203</DTSTART>",
+		"<TRNAMT>-23.99</TRNAMT>",
+		"<BALAMT>2301.59</BALAMT>",
+	} {
+		if !strings.Contains(first.String(), s) {
+			t.Errorf("Expected %s in the OFX output", s)
+		}
+	}
+}
+
+func Test_ofxV1(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewOFXWriter(&buf, &OFXOptions{Version: OFX_V1}).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(buf.String(), "OFXHEADER:100\nDATA:OFXSGML\nVERSION:102\n") {
+		t.Errorf("Expected an OFX 1 SGML header, but %q found", buf.String()[:40])
+	}
+
+	if !strings.Contains(buf.String(), "<TRNAMT>-23.99\n") {
+		t.Errorf("Expected unclosed value elements in the OFX 1 output")
+	}
+}
+
+func Test_ofxTransactionType(t *testing.T) {
+	tests := []struct {
+		concept  string
+		amount   float64
+		expected string
+	}{
+		{CONCEPT_INTEREST_FEES, 1, "INT"},
+		{CONCEPT_INTEREST_FEES, -1, "FEE"},
+		{CONCEPT_DIRECT_DEBITS, -1, "DIRECTDEBIT"},
+		{CONCEPT_CARDS, -1, "POS"},
+		{CONCEPT_MISCELLANEOUS, 1, "CREDIT"},
+		{"", -1, "DEBIT"},
+	}
+
+	for _, test := range tests {
+		m := &Movement{CommonConcept: test.concept, Amount: test.amount}
+		if trntype := OFXTransactionType(m); trntype != test.expected {
+			t.Errorf("Expected TRNTYPE of concept %q and amount %.2f to be %s, but %s found", test.concept, test.amount, test.expected, trntype)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package n43
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QIFWriter writes a Norma43 document as a QIF file with one bank section
+// per account.
+type QIFWriter struct {
+	w         *bufio.Writer
+	qifOption *QIFOptions
+}
+
+// QIFDateFormat is the order of the day, month and year in the QIF dates.
+type QIFDateFormat string
+
+const (
+	QIF_DMY QIFDateFormat = "DMY"
+	QIF_MDY QIFDateFormat = "MDY"
+	QIF_YMD QIFDateFormat = "YMD"
+)
+
+func (df *QIFDateFormat) String() string {
+	return string(*df)
+}
+
+func (df *QIFDateFormat) Set(val string) error {
+	switch QIFDateFormat(val) {
+	case QIF_DMY, QIF_MDY, QIF_YMD:
+		*df = QIFDateFormat(val)
+		return nil
+	}
+	return errors.New("invalid QIF date format " + val)
+}
+
+type QIFOptions struct {
+	// DateFormat is the order of the day, month and year in the dates,
+	// QIF_DMY by default.
+	DateFormat QIFDateFormat
+}
+
+func NewQIFWriter(w io.Writer, qifOptions *QIFOptions) *QIFWriter {
+	qo := new(QIFOptions)
+
+	qo.DateFormat = QIF_DMY
+
+	if qifOptions != nil && qifOptions.DateFormat != "" {
+		qo.DateFormat = qifOptions.DateFormat
+	}
+
+	return &QIFWriter{
+		w:         bufio.NewWriter(w),
+		qifOption: qo,
+	}
+}
+
+func (q *QIFWriter) Write(n *Norma43) error {
+	for _, account := range n.Accounts {
+		if len(n.Accounts) > 1 && account.Header != nil {
+			q.w.WriteString("!Account\n")
+			q.w.WriteString("N" + account.Header.BankCode + account.Header.BranchCode + account.Header.AccountNumber + "\n")
+			if name := strings.TrimSpace(account.Header.AccountName); name != "" {
+				q.w.WriteString("D" + name + "\n")
+			}
+			q.w.WriteString("TBank\n^\n")
+		}
+
+		q.w.WriteString("!Type:Bank\n")
+		for _, m := range account.Movements {
+			q.writeMovement(m)
+		}
+	}
+
+	return q.w.Flush()
+}
+
+func (q *QIFWriter) writeMovement(m *Movement) {
+	q.w.WriteString("D" + q.date(m.TransactionDate) + "\n")
+	q.w.WriteString("T" + strconv.FormatFloat(m.Amount, 'f', 2, 64) + "\n")
+
+	if doc := strings.TrimSpace(m.DocumentNumber); strings.Trim(doc, "0") != "" {
+		q.w.WriteString("N" + doc + "\n")
+	}
+
+	// The complementary concepts of a line are fixed width columns that cut
+	// words, so the payee is the whole first line and the memo the rest.
+	texts := make([]string, 0, len(m.ExtraInformation))
+	for _, line := range m.ExtraInformation {
+		if text := strings.Join(strings.Fields(line), " "); text != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	payee := strings.TrimSpace(m.Description)
+	if len(texts) > 0 {
+		payee, texts = texts[0], texts[1:]
+	}
+	if payee != "" {
+		q.w.WriteString("P" + payee + "\n")
+	}
+
+	if len(texts) > 0 {
+		q.w.WriteString("M" + strings.Join(texts, " ") + "\n")
+	}
+
+	// QIF has a single class per transaction, written after the category,
+	// so the tags become a class and its subclasses.
+	category := m.Category
+	if len(m.Tags) > 0 {
+		category += "/" + strings.Join(m.Tags, ":")
+	}
+	if category != "" {
+		q.w.WriteString("L" + category + "\n")
+	}
+
+	q.w.WriteString("^\n")
+}
+
+func (q *QIFWriter) date(t time.Time) string {
+	parts := make([]string, 0, 3)
+	for _, symbol := range q.qifOption.DateFormat {
+		switch symbol {
+		case 'D':
+			parts = append(parts, t.Format("02"))
+		case 'M':
+			parts = append(parts, t.Format("01"))
+		case 'Y':
+			parts = append(parts, t.Format("2006"))
+		}
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,74 @@
+package n43
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_qif(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewQIFWriter(&buf, nil).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "!Type:Bank\n") {
+		t.Errorf("Expected a bank section, but %q found", out)
+	}
+
+	if strings.Count(out, "^\n") != 5 {
+		t.Errorf("Expected 5 transactions, but %d found", strings.Count(out, "^\n"))
+	}
+
+	first := "D03/02/2020\nT-23.99\nPCOMPRA TARG 1234XXXXXXXX3456 SHOP TO BUY SEVERAL THINGS IN THERE.\n^\n"
+	if !strings.Contains(out, first) {
+		t.Errorf("Expected first transaction %q, but %q found", first, out)
+	}
+
+	last := "PCREDIT CARD 1234567890123456 1234 .CAR GARAGE REPAIR.\nMCREDIT CARD 1234567890123456 1234 .CAR GARAGE REPAIR EXTRA.\n^\n"
+	if !strings.HasSuffix(out, last) {
+		t.Errorf("Expected the first extra information line as payee and the rest as memo, but %q found", out)
+	}
+}
+
+func Test_qifDateFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewQIFWriter(&buf, &QIFOptions{DateFormat: QIF_MDY}).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "D02/03/2020\n") {
+		t.Errorf("Expected month first dates, but %q found", buf.String())
+	}
+
+	var df QIFDateFormat
+	if err := df.Set("MDY"); err != nil || df != QIF_MDY {
+		t.Errorf("Expected MDY to be a QIF date format, but %v found", err)
+	}
+	if err := df.Set("DM"); err == nil {
+		t.Errorf("Expected an error with an invalid QIF date format, but nothing found")
+	}
+	var tf TimeFormat
+	if err := tf.Set("MDY"); err == nil {
+		t.Errorf("Expected the parser to reject MDY dates, but nothing found")
+	}
+}
+
+func Test_qifCategory(t *testing.T) {
+	in := parseSample(t)
+	in.Accounts[0].Movements[0].Category = "Shopping"
+	in.Accounts[0].Movements[0].Tags = []string{"card", "home"}
+	in.Accounts[0].Movements[1].Tags = []string{"bills"}
+
+	var buf bytes.Buffer
+	if err := NewQIFWriter(&buf, nil).Write(in); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "\nLShopping/card:home\n^\n") || !strings.Contains(out, "\nL/bills\n^\n") {
+		t.Errorf("Expected the categories and the tags as classes, but %q found", out)
+	}
+}
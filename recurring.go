@@ -0,0 +1,280 @@
+package n43
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type Periodicity string
+
+const (
+	PERIODICITY_WEEKLY    Periodicity = "weekly"
+	PERIODICITY_MONTHLY   Periodicity = "monthly"
+	PERIODICITY_QUARTERLY Periodicity = "quarterly"
+	PERIODICITY_YEARLY    Periodicity = "yearly"
+)
+
+// RecurringPayment is a series of movements of an account with the same
+// counterparty and concept, similar amounts and a regular period.
+type RecurringPayment struct {
+	IBAN string `json:"iban"`
+	// Counterparty is the normalised text shared by the movements, see
+	// DetectRecurring.
+	Counterparty  string      `json:"counterparty"`
+	CommonConcept string      `json:"common_concept"`
+	Periodicity   Periodicity `json:"periodicity"`
+	Occurrences   []*Movement `json:"occurrences"`
+	AverageAmount float64     `json:"average_amount"`
+	// NextDate and NextAmount predict the next occurrence, with the amount of
+	// the last one.
+	NextDate   time.Time `json:"next_date"`
+	NextAmount float64   `json:"next_amount"`
+	// Missed are the expected dates without occurrence, up to the end of the
+	// latest statement.
+	Missed  []time.Time     `json:"missed"`
+	Changes []*AmountChange `json:"changes"`
+}
+
+// AmountChange is an occurrence whose amount differs from the previous one.
+type AmountChange struct {
+	Movement       *Movement `json:"movement"`
+	PreviousAmount float64   `json:"previous_amount"`
+}
+
+type RecurringOptions struct {
+	// AmountTolerance is the relative difference allowed between the amount
+	// of an occurrence and the median amount of its series, 0.1 (10%) by
+	// default.
+	AmountTolerance float64
+	// MinOccurrences is the number of movements needed to detect a series,
+	// 3 by default.
+	MinOccurrences int
+}
+
+// recurringPeriod describes a periodicity: its length in days, the days an
+// occurrence may be early or late, and the calendar step between
+// occurrences.
+type recurringPeriod struct {
+	periodicity Periodicity
+	days        float64
+	slack       float64
+	months      int
+	weeks       int
+}
+
+var recurringPeriods = []*recurringPeriod{
+	{PERIODICITY_WEEKLY, 7, 1, 0, 1},
+	{PERIODICITY_MONTHLY, 30.44, 4, 1, 0},
+	{PERIODICITY_QUARTERLY, 91.31, 7, 3, 0},
+	{PERIODICITY_YEARLY, 365.25, 10, 12, 0},
+}
+
+// next returns the date steps periods after first. Monthly steps keep the day
+// of the month of first, moved back to the last day of shorter months, so a
+// series paid at the end of the month does not drift.
+func (p *recurringPeriod) next(first time.Time, steps int) time.Time {
+	if p.months == 0 {
+		return first.AddDate(0, 0, 7*p.weeks*steps)
+	}
+
+	month := time.Date(first.Year(), first.Month()+time.Month(p.months*steps), 1, 0, 0, 0, 0, first.Location())
+	day := first.Day()
+	if last := month.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(month.Year(), month.Month(), day, first.Hour(), first.Minute(), first.Second(), first.Nanosecond(), first.Location())
+}
+
+// DetectRecurring finds the recurring payments and incomes of the documents,
+// like direct debits, subscriptions or payrolls. The statements of each
+// account are merged first, see Merge, so the series can span several
+// documents.
+//
+// Movements are grouped by account, common concept, sign and counterparty,
+// the first complementary concept, or the references when there is none,
+// without the words holding digits, like card numbers, dates or invoice
+// numbers. Each group is split into series of similar amounts, and a series
+// is recurring when the median interval between its movements matches a
+// periodicity and every interval is a whole number of periods, the extra
+// periods being missed occurrences.
+func DetectRecurring(docs []*Norma43, recurringOptions *RecurringOptions) []*RecurringPayment {
+	ro := new(RecurringOptions)
+
+	ro.AmountTolerance = 0.1
+	ro.MinOccurrences = 3
+
+	if recurringOptions != nil {
+		if recurringOptions.AmountTolerance > 0 {
+			ro.AmountTolerance = recurringOptions.AmountTolerance
+		}
+		if recurringOptions.MinOccurrences > 1 {
+			ro.MinOccurrences = recurringOptions.MinOccurrences
+		}
+	}
+
+	payments := []*RecurringPayment{}
+	for _, account := range Merge(docs).Accounts {
+		payments = append(payments, recurringPayments(account, ro)...)
+	}
+
+	return payments
+}
+
+func recurringPayments(account *Account, ro *RecurringOptions) []*RecurringPayment {
+	groups := map[string][]*Movement{}
+	keys := []string{}
+	for _, m := range account.Movements {
+		key := m.CommonConcept + "\x00" + counterparty(m)
+		if m.Amount < 0 {
+			key = "-" + key
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], m)
+	}
+	sort.Strings(keys)
+
+	payments := []*RecurringPayment{}
+	for _, key := range keys {
+		for _, series := range amountSeries(groups[key], ro.AmountTolerance) {
+			if len(series) < ro.MinOccurrences {
+				continue
+			}
+			if p := recurringPayment(account.Header, series); p != nil {
+				payments = append(payments, p)
+			}
+		}
+	}
+
+	return payments
+}
+
+// amountSeries splits movements sorted by date into series where every
+// amount is within the tolerance of the median amount of the series, so
+// small changes do not add up to a series of any amount.
+func amountSeries(movements []*Movement, tolerance float64) [][]*Movement {
+	series := [][]*Movement{}
+	for _, m := range movements {
+		found := false
+		for i, s := range series {
+			median := medianAmount(s)
+			if abs(m.Amount-median) <= tolerance*abs(median) {
+				series[i] = append(s, m)
+				found = true
+				break
+			}
+		}
+		if !found {
+			series = append(series, []*Movement{m})
+		}
+	}
+	return series
+}
+
+func medianAmount(movements []*Movement) float64 {
+	amounts := make([]float64, 0, len(movements))
+	for _, m := range movements {
+		amounts = append(amounts, m.Amount)
+	}
+	sort.Float64s(amounts)
+
+	if len(amounts)%2 == 0 {
+		return (amounts[len(amounts)/2-1] + amounts[len(amounts)/2]) / 2
+	}
+	return amounts[len(amounts)/2]
+}
+
+func recurringPayment(h *Header, occurrences []*Movement) *RecurringPayment {
+	intervals := make([]float64, 0, len(occurrences)-1)
+	for i := 1; i < len(occurrences); i++ {
+		intervals = append(intervals, occurrences[i].TransactionDate.Sub(occurrences[i-1].TransactionDate).Hours()/24)
+	}
+
+	sorted := append([]float64{}, intervals...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	var period *recurringPeriod
+	for _, p := range recurringPeriods {
+		if math.Abs(median-p.days) <= p.slack {
+			period = p
+			break
+		}
+	}
+	if period == nil {
+		return nil
+	}
+
+	first := occurrences[0]
+	rp := &RecurringPayment{
+		IBAN:          h.IBAN(),
+		Counterparty:  counterparty(first),
+		CommonConcept: first.CommonConcept,
+		Periodicity:   period.periodicity,
+		Occurrences:   occurrences,
+		Missed:        []time.Time{},
+		Changes:       []*AmountChange{},
+	}
+	if rp.Counterparty == "" {
+		rp.Counterparty = strings.ToUpper(ConceptDescription(first.CommonConcept))
+	}
+
+	// Expected dates are counted in periods from the first occurrence.
+	sum := first.Amount
+	periods := 0
+	for i, d := range intervals {
+		prev, m := occurrences[i], occurrences[i+1]
+
+		steps := int(math.Round(d / period.days))
+		if steps < 1 || math.Abs(d-float64(steps)*period.days) > period.slack*float64(steps) {
+			return nil
+		}
+		for j := 1; j < steps; j++ {
+			rp.Missed = append(rp.Missed, period.next(first.TransactionDate, periods+j))
+		}
+		periods += steps
+
+		if roundAmount(m.Amount) != roundAmount(prev.Amount) {
+			rp.Changes = append(rp.Changes, &AmountChange{Movement: m, PreviousAmount: prev.Amount})
+		}
+		sum += m.Amount
+	}
+
+	last := occurrences[len(occurrences)-1]
+	rp.AverageAmount = roundAmount(sum / float64(len(occurrences)))
+	rp.NextAmount = last.Amount
+
+	// Occurrences due before the end of the statements are missed too.
+	periods++
+	rp.NextDate = period.next(first.TransactionDate, periods)
+	for rp.NextDate.AddDate(0, 0, int(period.slack)).Before(h.EndDate) {
+		rp.Missed = append(rp.Missed, rp.NextDate)
+		periods++
+		rp.NextDate = period.next(first.TransactionDate, periods)
+	}
+
+	return rp
+}
+
+// counterparty returns the normalised text naming the counterparty of m.
+func counterparty(m *Movement) string {
+	text := m.Description
+	if concepts := m.ComplementaryConcepts(); len(concepts) > 0 {
+		text = concepts[0]
+	}
+
+	words := []string{}
+	for _, word := range strings.FieldsFunc(strings.ToUpper(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,121 @@
+package n43
+
+import (
+	"testing"
+	"time"
+)
+
+func recurringStatement(start time.Time, end time.Time, movements ...*Movement) *Norma43 {
+	h := &Header{BankCode: "1111", BranchCode: "2222", AccountNumber: "3333444412", StartDate: start, EndDate: end, Currency: "978"}
+	a := &Account{Header: h, Movements: movements}
+	a.Footer = a.ComputeFooter()
+	return &Norma43{Accounts: []*Account{a}}
+}
+
+func recurringMovement(date time.Time, concept string, amount float64, text string) *Movement {
+	return &Movement{
+		TransactionDate:  date,
+		ValueDate:        date,
+		CommonConcept:    concept,
+		Amount:           amount,
+		ExtraInformation: extraInformation([]string{text}),
+	}
+}
+
+func Test_detectRecurring(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2020, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	first := recurringStatement(day(1, 1), day(3, 31),
+		recurringMovement(day(1, 5), CONCEPT_DIRECT_DEBITS, -12.99, "RECIBO STREAMING SA 2020/01"),
+		recurringMovement(day(1, 6), CONCEPT_CARDS, -45.20, "COMPRA TARJ. 1234XXXXXXXX5678 MERCADO"),
+		recurringMovement(day(1, 27), CONCEPT_CARDS, -3.10, "COMPRA TARJ. 1234XXXXXXXX5678 MERCADO"),
+		recurringMovement(day(1, 30), CONCEPT_PAYROLL, 1800, "NOMINA EMPRESA SL"),
+		recurringMovement(day(2, 4), CONCEPT_DIRECT_DEBITS, -12.99, "RECIBO STREAMING SA 2020/02"),
+		recurringMovement(day(2, 10), CONCEPT_CARDS, -120.75, "COMPRA TARJ. 1234XXXXXXXX5678 MERCADO"),
+		recurringMovement(day(2, 28), CONCEPT_PAYROLL, 1800, "NOMINA EMPRESA SL"),
+		recurringMovement(day(3, 5), CONCEPT_DIRECT_DEBITS, -12.99, "RECIBO STREAMING SA 2020/03"),
+		recurringMovement(day(3, 30), CONCEPT_PAYROLL, 1850, "NOMINA EMPRESA SL"),
+	)
+	second := recurringStatement(day(4, 1), day(6, 30),
+		recurringMovement(day(4, 30), CONCEPT_PAYROLL, 1850, "NOMINA EMPRESA SL"),
+		recurringMovement(day(5, 5), CONCEPT_DIRECT_DEBITS, -13.99, "RECIBO STREAMING SA 2020/05"),
+		recurringMovement(day(6, 5), CONCEPT_DIRECT_DEBITS, -13.99, "RECIBO STREAMING SA 2020/06"),
+	)
+
+	payments := DetectRecurring([]*Norma43{first, second}, nil)
+	if len(payments) != 2 {
+		t.Fatalf("Expected 2 recurring payments, but %d found", len(payments))
+	}
+
+	debit, payroll := payments[0], payments[1]
+	if debit.Counterparty != "RECIBO STREAMING SA" || debit.Periodicity != PERIODICITY_MONTHLY || len(debit.Occurrences) != 5 {
+		t.Errorf("Expected the monthly direct debit, but %+v found", debit)
+	}
+	if len(debit.Missed) != 1 || !debit.Missed[0].Equal(day(4, 5)) {
+		t.Errorf("Expected the April direct debit to be missed, but %v found", debit.Missed)
+	}
+	if len(debit.Changes) != 1 || debit.Changes[0].Movement.Amount != -13.99 || debit.Changes[0].PreviousAmount != -12.99 {
+		t.Errorf("Expected the amount change in May, but %+v found", debit.Changes)
+	}
+	if !debit.NextDate.Equal(day(7, 5)) || debit.NextAmount != -13.99 || debit.AverageAmount != -13.39 {
+		t.Errorf("Expected the next direct debit on 2020-07-05 of -13.99, but %s %.2f found", debit.NextDate.Format("2006-01-02"), debit.NextAmount)
+	}
+
+	if payroll.Counterparty != "NOMINA EMPRESA SL" || len(payroll.Occurrences) != 4 || len(payroll.Changes) != 1 {
+		t.Errorf("Expected the monthly payroll, but %+v found", payroll)
+	}
+	if len(payroll.Missed) != 1 || !payroll.Missed[0].Equal(day(5, 30)) || !payroll.NextDate.Equal(day(6, 30)) {
+		t.Errorf("Expected the May payroll to be missed, but %v found", payroll.Missed)
+	}
+
+	if payments := DetectRecurring([]*Norma43{first, second}, &RecurringOptions{AmountTolerance: 0.01}); len(payments) != 1 {
+		t.Errorf("Expected only the first amounts of the direct debit with a lower tolerance, but %d payments found", len(payments))
+	}
+	if payments := DetectRecurring([]*Norma43{parseSample(t)}, nil); len(payments) != 0 {
+		t.Errorf("Expected no recurring payments in the sample, but %d found", len(payments))
+	}
+}
+
+func Test_detectRecurringMonthEnd(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2020, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	doc := recurringStatement(day(1, 1), day(6, 30),
+		recurringMovement(day(1, 31), CONCEPT_DIRECT_DEBITS, -50, "RECIBO ALQUILER"),
+		recurringMovement(day(2, 29), CONCEPT_DIRECT_DEBITS, -50, "RECIBO ALQUILER"),
+		recurringMovement(day(3, 31), CONCEPT_DIRECT_DEBITS, -50, "RECIBO ALQUILER"),
+		recurringMovement(day(4, 30), CONCEPT_DIRECT_DEBITS, -50, "RECIBO ALQUILER"),
+		recurringMovement(day(6, 30), CONCEPT_DIRECT_DEBITS, -50, "RECIBO ALQUILER"),
+	)
+
+	payments := DetectRecurring([]*Norma43{doc}, nil)
+	if len(payments) != 1 {
+		t.Fatalf("Expected 1 recurring payment, but %d found", len(payments))
+	}
+	if p := payments[0]; len(p.Missed) != 1 || !p.Missed[0].Equal(day(5, 31)) || !p.NextDate.Equal(day(7, 31)) {
+		t.Errorf("Expected the payment of 2020-05-31 missed and the next one on 2020-07-31, but %v and %s found", p.Missed, p.NextDate.Format("2006-01-02"))
+	}
+}
+
+func Test_detectRecurringAmountDrift(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2020, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	// Every amount is within 10% of the previous one, but not of the series.
+	amounts := []float64{-100, -109, -118, -128, -139, -151}
+	movements := []*Movement{}
+	for i, amount := range amounts {
+		movements = append(movements, recurringMovement(day(time.Month(i+1), 5), CONCEPT_DIRECT_DEBITS, amount, "RECIBO GIMNASIO"))
+	}
+	doc := recurringStatement(day(1, 1), day(6, 30), movements...)
+
+	for _, p := range DetectRecurring([]*Norma43{doc}, nil) {
+		if p.Occurrences[0].Amount-p.Occurrences[len(p.Occurrences)-1].Amount > 15 {
+			t.Errorf("Expected the series to keep close amounts, but %.2f to %.2f found", p.Occurrences[0].Amount, p.Occurrences[len(p.Occurrences)-1].Amount)
+		}
+	}
+}
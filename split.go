@@ -0,0 +1,24 @@
+package n43
+
+// Split returns a document per account, each with the end of file record
+// count of its own records, so they can be written as separate files.
+func (n *Norma43) Split() []*Norma43 {
+	docs := make([]*Norma43, 0, len(n.Accounts))
+	for _, account := range n.Accounts {
+		docs = append(docs, &Norma43{
+			Accounts:        []*Account{account},
+			ReportedEntries: account.Records(),
+		})
+	}
+	return docs
+}
+
+// Records returns the number of records of the account: the header, the
+// movements with their extra information lines and the footer.
+func (a *Account) Records() int {
+	records := 2 + len(a.Movements)
+	for _, m := range a.Movements {
+		records += len(m.ExtraInformation)
+	}
+	return records
+}
@@ -0,0 +1,59 @@
+package n43
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_multipleAccounts(t *testing.T) {
+	second := parseSample(t).Accounts[0]
+	second.Header.AccountNumber = "5555666677"
+	second.Footer = nil
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf, nil).Write(&Norma43{Accounts: []*Account{parseSample(t).Accounts[0], second}}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := NewParser(strings.Split(buf.String(), "\n"), &ParserOptions{Trim: true}).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out.Accounts) != 2 {
+		t.Fatalf("Expected 2 accounts, but %d found", len(out.Accounts))
+	}
+	if out.Accounts[1].Header.AccountNumber != "5555666677" || len(out.Accounts[1].Movements) != 5 || out.Accounts[1].Footer == nil {
+		t.Errorf("Expected the second account, but %+v found", out.Accounts[1])
+	}
+	if out.Accounts[1].Movements[0].Balance != 2439.44 {
+		t.Errorf("Expected the balance of the second account to start again, but %.2f found", out.Accounts[1].Movements[0].Balance)
+	}
+	if out.ReportedEntries != 26 {
+		t.Errorf("Expected 26 reported entries, but %d found", out.ReportedEntries)
+	}
+
+	docs := out.Split()
+	if len(docs) != 2 {
+		t.Fatalf("Expected 2 documents, but %d found", len(docs))
+	}
+	for i, doc := range docs {
+		if len(doc.Accounts) != 1 || doc.Accounts[0] != out.Accounts[i] || doc.ReportedEntries != 13 {
+			t.Errorf("Expected document %d to hold account %d with 13 records, but %+v found", i, i, doc)
+		}
+	}
+}
+
+func Test_footerWithoutEndOfFile(t *testing.T) {
+	lines := strings.Split(sampleData, "\n")
+	lines[len(lines)-1] = ""
+
+	out, err := NewParser(lines, &ParserOptions{Trim: true}).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Accounts) != 1 || out.Accounts[0].Footer == nil || out.ReportedEntries != 0 {
+		t.Errorf("Expected a single account without end of file record, but %+v found", out)
+	}
+}
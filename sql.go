@@ -0,0 +1,232 @@
+package n43
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SQLDialect string
+
+const (
+	SQLITE     SQLDialect = "sqlite"
+	POSTGRESQL SQLDialect = "postgres"
+)
+
+// SQLWriter writes a Norma43 document as SQL statements loading it into the
+// accounts, statements, movements, complementary_concepts and tags tables. Every
+// insert is an upsert, so loading the same file twice leaves the database
+// unchanged. Movements are keyed on their account IBAN and fingerprint, as
+// the same movement may be found in two accounts.
+type SQLWriter struct {
+	w         *bufio.Writer
+	sqlOption *SQLOptions
+}
+
+type SQLOptions struct {
+	// Dialect is the SQL flavour of the statements, SQLITE by default.
+	Dialect SQLDialect
+	// SkipSchema leaves out the statements creating the tables.
+	SkipSchema bool
+}
+
+// sqlTypes holds the column types of each dialect.
+var sqlTypes = map[SQLDialect]map[string]string{
+	SQLITE: {
+		"text":    "TEXT",
+		"date":    "TEXT",
+		"amount":  "NUMERIC",
+		"integer": "INTEGER",
+	},
+	POSTGRESQL: {
+		"text":    "VARCHAR(255)",
+		"date":    "DATE",
+		"amount":  "NUMERIC(15,2)",
+		"integer": "INTEGER",
+	},
+}
+
+const sqlSchema = `CREATE TABLE IF NOT EXISTS accounts (
+    iban {{text}} PRIMARY KEY,
+    bank_code {{text}} NOT NULL,
+    branch_code {{text}} NOT NULL,
+    account_number {{text}} NOT NULL,
+    currency {{text}} NOT NULL,
+    account_name {{text}} NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS statements (
+    id {{text}} PRIMARY KEY,
+    iban {{text}} NOT NULL REFERENCES accounts (iban),
+    start_date {{date}} NOT NULL,
+    end_date {{date}} NOT NULL,
+    initial_balance {{amount}} NOT NULL,
+    final_balance {{amount}} NOT NULL,
+    debit_entries {{integer}} NOT NULL,
+    debit_amount {{amount}} NOT NULL,
+    credit_entries {{integer}} NOT NULL,
+    credit_amount {{amount}} NOT NULL,
+    information_mode_code {{text}} NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS movements (
+    iban {{text}} NOT NULL REFERENCES accounts (iban),
+    fingerprint {{text}} NOT NULL,
+    statement_id {{text}} NOT NULL REFERENCES statements (id),
+    position {{integer}} NOT NULL,
+    branch_code {{text}} NOT NULL,
+    transaction_date {{date}} NOT NULL,
+    value_date {{date}} NOT NULL,
+    common_concept {{text}} NOT NULL,
+    own_concept {{text}} NOT NULL,
+    amount {{amount}} NOT NULL,
+    balance {{amount}} NOT NULL,
+    document_number {{text}} NOT NULL,
+    description {{text}} NOT NULL,
+    category {{text}} NOT NULL,
+    PRIMARY KEY (iban, fingerprint)
+);
+
+CREATE TABLE IF NOT EXISTS complementary_concepts (
+    iban {{text}} NOT NULL,
+    fingerprint {{text}} NOT NULL,
+    position {{integer}} NOT NULL,
+    concept {{text}} NOT NULL,
+    PRIMARY KEY (iban, fingerprint, position),
+    FOREIGN KEY (iban, fingerprint) REFERENCES movements (iban, fingerprint)
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+    iban {{text}} NOT NULL,
+    fingerprint {{text}} NOT NULL,
+    tag {{text}} NOT NULL,
+    PRIMARY KEY (iban, fingerprint, tag),
+    FOREIGN KEY (iban, fingerprint) REFERENCES movements (iban, fingerprint)
+);
+
+`
+
+func NewSQLWriter(w io.Writer, sqlOptions *SQLOptions) *SQLWriter {
+	so := new(SQLOptions)
+
+	so.Dialect = SQLITE
+
+	if sqlOptions != nil {
+		if sqlOptions.Dialect != "" {
+			so.Dialect = sqlOptions.Dialect
+		}
+		so.SkipSchema = sqlOptions.SkipSchema
+	}
+
+	return &SQLWriter{
+		w:         bufio.NewWriter(w),
+		sqlOption: so,
+	}
+}
+
+func (s *SQLWriter) Write(n *Norma43) error {
+	types, ok := sqlTypes[s.sqlOption.Dialect]
+	if !ok {
+		return errors.New("unsupported SQL dialect " + string(s.sqlOption.Dialect))
+	}
+
+	if !s.sqlOption.SkipSchema {
+		schema := sqlSchema
+		for name, kind := range types {
+			schema = strings.ReplaceAll(schema, "{{"+name+"}}", kind)
+		}
+		s.w.WriteString(schema)
+	}
+
+	s.w.WriteString("BEGIN;\n\n")
+
+	for _, account := range n.Accounts {
+		h := account.Header
+		if h == nil {
+			h = new(Header)
+		}
+		iban := h.IBAN()
+		statement := iban + "-" + h.StartDate.Format("20060102") + "-" + h.EndDate.Format("20060102")
+
+		s.upsert("accounts", []string{"iban"},
+			[]string{"iban", "bank_code", "branch_code", "account_number", "currency", "account_name"},
+			[]string{sqlString(iban), sqlString(h.BankCode), sqlString(h.BranchCode), sqlString(h.AccountNumber), sqlString(h.Currency), sqlString(strings.TrimSpace(h.AccountName))})
+
+		f := account.Footer
+		if f == nil {
+			f = account.ComputeFooter()
+		}
+		s.upsert("statements", []string{"id"},
+			[]string{"id", "iban", "start_date", "end_date", "initial_balance", "final_balance", "debit_entries", "debit_amount", "credit_entries", "credit_amount", "information_mode_code"},
+			[]string{sqlString(statement), sqlString(iban), sqlDate(h.StartDate), sqlDate(h.EndDate), sqlAmount(h.InitialBalance), sqlAmount(f.FinalBalance), strconv.Itoa(f.DebitEntries), sqlAmount(f.DebitAmount), strconv.Itoa(f.CreditEntries), sqlAmount(f.CreditAmount), sqlString(h.InformationModeCode)})
+
+		fingerprints := account.Fingerprints()
+		for i, m := range account.Movements {
+			s.upsert("movements", []string{"iban", "fingerprint"},
+				[]string{"iban", "fingerprint", "statement_id", "position", "branch_code", "transaction_date", "value_date", "common_concept", "own_concept", "amount", "balance", "document_number", "description", "category"},
+				[]string{sqlString(iban), sqlString(fingerprints[i]), sqlString(statement), strconv.Itoa(i + 1), sqlString(m.BranchCode), sqlDate(m.TransactionDate), sqlDate(m.ValueDate), sqlString(m.CommonConcept), sqlString(m.OwnConcept), sqlAmount(m.Amount), sqlAmount(m.Balance), sqlString(strings.TrimSpace(m.DocumentNumber)), sqlString(strings.TrimSpace(m.Description)), sqlString(m.Category)})
+
+			// The concepts are replaced as a whole, a movement written again
+			// may have fewer of them.
+			s.w.WriteString("DELETE FROM complementary_concepts WHERE iban = " + sqlString(iban) + " AND fingerprint = " + sqlString(fingerprints[i]) + ";\n")
+			for j, concept := range m.ComplementaryConcepts() {
+				s.upsert("complementary_concepts", []string{"iban", "fingerprint", "position"},
+					[]string{"iban", "fingerprint", "position", "concept"},
+					[]string{sqlString(iban), sqlString(fingerprints[i]), strconv.Itoa(j + 1), sqlString(strings.TrimSpace(concept))})
+			}
+
+			// So are the tags, the categorization rules may have changed.
+			s.w.WriteString("DELETE FROM tags WHERE iban = " + sqlString(iban) + " AND fingerprint = " + sqlString(fingerprints[i]) + ";\n")
+			for _, tag := range m.Tags {
+				s.upsert("tags", []string{"iban", "fingerprint", "tag"},
+					[]string{"iban", "fingerprint", "tag"},
+					[]string{sqlString(iban), sqlString(fingerprints[i]), sqlString(tag)})
+			}
+		}
+		s.w.WriteString("\n")
+	}
+
+	s.w.WriteString("COMMIT;\n")
+
+	return s.w.Flush()
+}
+
+// upsert writes an insert updating every non key column when the row with
+// the same keys already exists. Both SQLite and PostgreSQL understand the
+// ON CONFLICT clause.
+func (s *SQLWriter) upsert(table string, keys []string, columns []string, values []string) {
+	updates := []string{}
+	for _, column := range columns {
+		isKey := false
+		for _, key := range keys {
+			isKey = isKey || key == column
+		}
+		if !isKey {
+			updates = append(updates, column+" = excluded."+column)
+		}
+	}
+
+	s.w.WriteString("INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(values, ", ") + ")")
+	s.w.WriteString(" ON CONFLICT (" + strings.Join(keys, ", ") + ")")
+	if len(updates) > 0 {
+		s.w.WriteString(" DO UPDATE SET " + strings.Join(updates, ", "))
+	} else {
+		s.w.WriteString(" DO NOTHING")
+	}
+	s.w.WriteString(";\n")
+}
+
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func sqlDate(t time.Time) string {
+	return "'" + t.Format("2006-01-02") + "'"
+}
+
+func sqlAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
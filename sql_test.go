@@ -0,0 +1,108 @@
+package n43
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_sql(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewSQLWriter(&buf, nil).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{
+		"PRIMARY KEY (iban, fingerprint)\n);",
+		"INSERT INTO accounts (iban, bank_code, branch_code, account_number, currency, account_name) VALUES ('ES4811112222033333444412', '1111', '2222', '3333444412', '978', 'ACCOUNT NAME ************') ON CONFLICT (iban) DO UPDATE SET",
+		"'ES4811112222033333444412-20200203-20200210', 'ES4811112222033333444412', '2020-02-03', '2020-02-10', 2463.43, 2301.59, 15, 661.84, 1, 500.00, '3')",
+		"ON CONFLICT (iban, fingerprint, position) DO UPDATE SET concept = excluded.concept;",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in the SQL output, but %q found", s, out)
+		}
+	}
+
+	if strings.Count(out, "INSERT INTO movements") != 5 {
+		t.Errorf("Expected 5 movements, but %d found", strings.Count(out, "INSERT INTO movements"))
+	}
+
+	i := strings.Index(out, "DELETE FROM complementary_concepts WHERE iban = 'ES4811112222033333444412' AND fingerprint = ")
+	if i < 0 || strings.Count(out, "DELETE FROM complementary_concepts") != 5 || i > strings.Index(out, "INSERT INTO complementary_concepts") {
+		t.Errorf("Expected the concepts of every movement to be deleted before inserting them, but %q found", out)
+	}
+}
+
+func Test_sqlCategories(t *testing.T) {
+	in := parseSample(t)
+	in.Accounts[0].Movements[0].Category = "Shopping"
+	in.Accounts[0].Movements[0].Tags = []string{"card", "home"}
+
+	var buf bytes.Buffer
+	if err := NewSQLWriter(&buf, nil).Write(in); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	fingerprint := in.Accounts[0].Fingerprints()[0]
+	for _, s := range []string{
+		"'0000000000001234567890123456', 'Shopping') ON CONFLICT (iban, fingerprint)",
+		"DELETE FROM tags WHERE iban = 'ES4811112222033333444412' AND fingerprint = '" + fingerprint + "';\nINSERT INTO tags (iban, fingerprint, tag) VALUES ('ES4811112222033333444412', '" + fingerprint + "', 'card') ON CONFLICT (iban, fingerprint, tag) DO NOTHING;\n",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected %q in the SQL output, but %q found", s, out)
+		}
+	}
+	if strings.Count(out, "INSERT INTO tags") != 2 {
+		t.Errorf("Expected 2 tags, but %d found", strings.Count(out, "INSERT INTO tags"))
+	}
+}
+
+func Test_sqlAccounts(t *testing.T) {
+	// The same fee charged on the same day to two accounts.
+	in := parseSample(t)
+	second := parseSample(t).Accounts[0]
+	second.Header.AccountNumber = "5555666612"
+	in.Accounts = append(in.Accounts, second)
+
+	var buf bytes.Buffer
+	if err := NewSQLWriter(&buf, &SQLOptions{SkipSchema: true}).Write(in); err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprint := in.Accounts[0].Fingerprints()[0]
+	if fingerprint != second.Fingerprints()[0] {
+		t.Fatalf("Expected both accounts to share the fingerprint %s", fingerprint)
+	}
+	for _, iban := range []string{in.Accounts[0].Header.IBAN(), second.Header.IBAN()} {
+		key := "INSERT INTO movements (iban, fingerprint, statement_id, position, branch_code, transaction_date, value_date, common_concept, own_concept, amount, balance, document_number, description, category) VALUES ('" + iban + "', '" + fingerprint + "'"
+		if strings.Count(buf.String(), key) != 1 {
+			t.Errorf("Expected the movement %s of %s to be keyed on its account, but %q found", fingerprint, iban, buf.String())
+		}
+	}
+}
+
+func Test_sqlDialect(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewSQLWriter(&buf, &SQLOptions{Dialect: POSTGRESQL}).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "transaction_date DATE NOT NULL") || !strings.Contains(buf.String(), "amount NUMERIC(15,2) NOT NULL") {
+		t.Errorf("Expected PostgreSQL column types, but %q found", buf.String())
+	}
+
+	buf.Reset()
+	if err := NewSQLWriter(&buf, &SQLOptions{SkipSchema: true}).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(buf.String(), "CREATE TABLE") {
+		t.Errorf("Expected no schema, but %q found", buf.String())
+	}
+
+	if err := NewSQLWriter(&buf, &SQLOptions{Dialect: "oracle"}).Write(parseSample(t)); err == nil {
+		t.Errorf("Expected an error for an unsupported dialect")
+	}
+}
@@ -0,0 +1,245 @@
+package n43
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Summary holds the statistics of one or more Norma43 documents. The
+// statements of the same account found in several documents are combined
+// into a single AccountSummary.
+type Summary struct {
+	Accounts []*AccountSummary `json:"accounts"`
+	// Totals adds up the movements of every account, whatever their
+	// currency.
+	Totals *Totals `json:"totals"`
+}
+
+type AccountSummary struct {
+	IBAN          string `json:"iban"`
+	BankCode      string `json:"bank_code"`
+	BranchCode    string `json:"branch_code"`
+	AccountNumber string `json:"account_number"`
+	AccountName   string `json:"account_name"`
+	Currency      string `json:"currency"`
+	// Statements is the number of headers of the account.
+	Statements int       `json:"statements"`
+	StartDate  time.Time `json:"start_date"`
+	EndDate    time.Time `json:"end_date"`
+	// OpeningBalance is the initial balance of the earliest statement and
+	// ClosingBalance the final balance of the latest one.
+	OpeningBalance float64 `json:"opening_balance"`
+	ClosingBalance float64 `json:"closing_balance"`
+	*Totals
+	// Largest are the movements with the largest amounts, debit or credit.
+	Largest []*Movement `json:"largest"`
+}
+
+type Totals struct {
+	Movements     int     `json:"movements"`
+	DebitEntries  int     `json:"debit_entries"`
+	DebitAmount   float64 `json:"debit_amount"`
+	CreditEntries int     `json:"credit_entries"`
+	CreditAmount  float64 `json:"credit_amount"`
+	AverageDebit  float64 `json:"average_debit"`
+	AverageCredit float64 `json:"average_credit"`
+	// ByConcept groups the movements by common concept code and ByMonth by
+	// the year and month of their transaction date, formatted as 2006-01.
+	ByConcept []*SummaryGroup `json:"by_concept"`
+	ByMonth   []*SummaryGroup `json:"by_month"`
+	// ByCategory groups the movements by the category set by a Categorizer,
+	// the uncategorized ones under an empty key. It is empty when no movement
+	// has a category.
+	ByCategory []*SummaryGroup `json:"by_category,omitempty"`
+}
+
+type SummaryGroup struct {
+	Key          string  `json:"key"`
+	Entries      int     `json:"entries"`
+	DebitAmount  float64 `json:"debit_amount"`
+	CreditAmount float64 `json:"credit_amount"`
+	Net          float64 `json:"net"`
+}
+
+type SummaryOptions struct {
+	// Largest is the number of movements kept in AccountSummary.Largest, 5
+	// by default.
+	Largest int
+}
+
+// Summarize returns the statistics of the documents.
+func Summarize(docs []*Norma43, summaryOptions *SummaryOptions) *Summary {
+	so := new(SummaryOptions)
+
+	so.Largest = 5
+
+	if summaryOptions != nil && summaryOptions.Largest > 0 {
+		so.Largest = summaryOptions.Largest
+	}
+
+	s := new(Summary)
+
+	// The statements of each account, in order of appearance.
+	statements := map[string][]*Account{}
+	order := []string{}
+	for _, doc := range docs {
+		for _, account := range doc.Accounts {
+			key := ""
+			if account.Header != nil {
+				key = account.Header.IBAN()
+			}
+			if _, ok := statements[key]; !ok {
+				order = append(order, key)
+			}
+			statements[key] = append(statements[key], account)
+		}
+	}
+
+	all := []*Movement{}
+	for _, key := range order {
+		as := summarizeAccount(statements[key], so.Largest)
+		s.Accounts = append(s.Accounts, as)
+
+		for _, account := range statements[key] {
+			all = append(all, account.Movements...)
+		}
+	}
+	s.Totals = newTotals(all)
+
+	return s
+}
+
+func summarizeAccount(statements []*Account, largest int) *AccountSummary {
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statementStart(statements[i]).Before(statementStart(statements[j]))
+	})
+
+	as := new(AccountSummary)
+	as.Statements = len(statements)
+
+	movements := []*Movement{}
+	for i, account := range statements {
+		movements = append(movements, account.Movements...)
+
+		h := account.Header
+		if h == nil {
+			continue
+		}
+		if i == 0 {
+			as.IBAN = h.IBAN()
+			as.BankCode = h.BankCode
+			as.BranchCode = h.BranchCode
+			as.AccountNumber = h.AccountNumber
+			as.AccountName = strings.TrimSpace(h.AccountName)
+			as.Currency = CurrencyCode(h.Currency)
+			as.StartDate = h.StartDate
+			as.OpeningBalance = h.InitialBalance
+		}
+		if !h.EndDate.Before(as.EndDate) {
+			as.EndDate = h.EndDate
+			as.ClosingBalance = accountFinalBalance(account)
+		}
+	}
+
+	as.Totals = newTotals(movements)
+
+	as.Largest = make([]*Movement, len(movements))
+	copy(as.Largest, movements)
+	sort.SliceStable(as.Largest, func(i, j int) bool {
+		return abs(as.Largest[i].Amount) > abs(as.Largest[j].Amount)
+	})
+	if len(as.Largest) > largest {
+		as.Largest = as.Largest[:largest]
+	}
+
+	return as
+}
+
+func newTotals(movements []*Movement) *Totals {
+	t := new(Totals)
+
+	concepts := map[string]*SummaryGroup{}
+	months := map[string]*SummaryGroup{}
+	categories := map[string]*SummaryGroup{}
+	categorized := false
+
+	for _, m := range movements {
+		t.Movements++
+		if m.Amount < 0 {
+			t.DebitEntries++
+			t.DebitAmount += -m.Amount
+		} else {
+			t.CreditEntries++
+			t.CreditAmount += m.Amount
+		}
+
+		addToGroup(concepts, m.CommonConcept, m)
+		addToGroup(months, m.TransactionDate.Format("2006-01"), m)
+		addToGroup(categories, m.Category, m)
+		if m.Category != "" {
+			categorized = true
+		}
+	}
+
+	t.DebitAmount = roundAmount(t.DebitAmount)
+	t.CreditAmount = roundAmount(t.CreditAmount)
+	if t.DebitEntries > 0 {
+		t.AverageDebit = roundAmount(t.DebitAmount / float64(t.DebitEntries))
+	}
+	if t.CreditEntries > 0 {
+		t.AverageCredit = roundAmount(t.CreditAmount / float64(t.CreditEntries))
+	}
+
+	t.ByConcept = sortedGroups(concepts)
+	t.ByMonth = sortedGroups(months)
+	if categorized {
+		t.ByCategory = sortedGroups(categories)
+	}
+
+	return t
+}
+
+func addToGroup(groups map[string]*SummaryGroup, key string, m *Movement) {
+	g, ok := groups[key]
+	if !ok {
+		g = &SummaryGroup{Key: key}
+		groups[key] = g
+	}
+
+	g.Entries++
+	if m.Amount < 0 {
+		g.DebitAmount += -m.Amount
+	} else {
+		g.CreditAmount += m.Amount
+	}
+}
+
+func sortedGroups(groups map[string]*SummaryGroup) []*SummaryGroup {
+	sorted := make([]*SummaryGroup, 0, len(groups))
+	for _, g := range groups {
+		g.DebitAmount = roundAmount(g.DebitAmount)
+		g.CreditAmount = roundAmount(g.CreditAmount)
+		g.Net = roundAmount(g.CreditAmount - g.DebitAmount)
+		sorted = append(sorted, g)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+func statementStart(a *Account) time.Time {
+	if a.Header == nil {
+		return time.Time{}
+	}
+	return a.Header.StartDate
+}
+
+func abs(amount float64) float64 {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
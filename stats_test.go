@@ -0,0 +1,66 @@
+package n43
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_summarize(t *testing.T) {
+	s := Summarize([]*Norma43{parseSample(t)}, &SummaryOptions{Largest: 2})
+
+	if len(s.Accounts) != 1 {
+		t.Fatalf("Expected 1 account, but %d found", len(s.Accounts))
+	}
+
+	as := s.Accounts[0]
+	if as.IBAN != "ES4811112222033333444412" || as.Currency != "EUR" || as.Statements != 1 {
+		t.Errorf("Expected the sample account, but %+v found", as)
+	}
+	if !as.StartDate.Equal(time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC)) || !as.EndDate.Equal(time.Date(2020, 2, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected period 2020-02-03 to 2020-02-10, but %s to %s found", as.StartDate, as.EndDate)
+	}
+	if as.OpeningBalance != 2463.43 || as.ClosingBalance != 2301.59 {
+		t.Errorf("Expected balances 2463.43 and 2301.59, but %.2f and %.2f found", as.OpeningBalance, as.ClosingBalance)
+	}
+	if as.DebitEntries != 4 || as.DebitAmount != 165.57 || as.CreditEntries != 1 || as.CreditAmount != 138.57 {
+		t.Errorf("Expected 4 debits of 165.57 and 1 credit of 138.57, but %+v found", as.Totals)
+	}
+	if as.AverageDebit != 41.39 || as.AverageCredit != 138.57 {
+		t.Errorf("Expected averages 41.39 and 138.57, but %.2f and %.2f found", as.AverageDebit, as.AverageCredit)
+	}
+	if len(as.Largest) != 2 || as.Largest[0].Amount != 138.57 || as.Largest[1].Amount != -70.29 {
+		t.Errorf("Expected the 2 largest movements, but %+v found", as.Largest)
+	}
+
+	if len(as.ByConcept) != 2 || as.ByConcept[0].Key != "03" || as.ByConcept[0].Entries != 2 || as.ByConcept[0].Net != -140.58 {
+		t.Errorf("Expected 2 concept groups starting with 03, but %+v found", as.ByConcept)
+	}
+	if len(as.ByMonth) != 1 || as.ByMonth[0].Key != "2020-02" || as.ByMonth[0].Net != -27 {
+		t.Errorf("Expected a single 2020-02 group, but %+v found", as.ByMonth)
+	}
+}
+
+func Test_summarizeCombined(t *testing.T) {
+	first := parseSample(t)
+	second := parseSample(t)
+	h := second.Accounts[0].Header
+	h.StartDate = h.StartDate.AddDate(0, 1, 0)
+	h.EndDate = h.EndDate.AddDate(0, 1, 0)
+	h.InitialBalance = 2301.59
+	second.Accounts[0].Footer.FinalBalance = 2000
+
+	// Statements are ordered by date, whatever the order of the documents.
+	s := Summarize([]*Norma43{second, first}, nil)
+
+	if len(s.Accounts) != 1 {
+		t.Fatalf("Expected 1 combined account, but %d found", len(s.Accounts))
+	}
+
+	as := s.Accounts[0]
+	if as.Statements != 2 || as.OpeningBalance != 2463.43 || as.ClosingBalance != 2000 {
+		t.Errorf("Expected 2 statements from 2463.43 to 2000.00, but %d from %.2f to %.2f found", as.Statements, as.OpeningBalance, as.ClosingBalance)
+	}
+	if as.Movements != 10 || s.Totals.Movements != 10 || len(as.Largest) != 5 {
+		t.Errorf("Expected 10 movements and 5 largest, but %d, %d and %d found", as.Movements, s.Totals.Movements, len(as.Largest))
+	}
+}
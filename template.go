@@ -0,0 +1,106 @@
+package n43
+
+import (
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// dateLayouts maps the day, month and year orders to the layouts used by
+// the date template function.
+var dateLayouts = map[string]string{
+	string(SPANISH_DATE): "02/01/2006",
+	string(ENGLISH_DATE): "2006-01-02",
+	"MDY":                "01/02/2006",
+}
+
+// TemplateFuncs returns the functions available to the output templates:
+//
+//	date t [layout]          formats a date, the layout is DMY, YMD, MDY or a Go
+//	                         layout, YMD by default
+//	amount x [options...]    formats an amount, the options are es (1.234,56),
+//	                         en (1,234.56), sign (always show the sign) and abs
+//	pad n s, padLeft n s     pads s with spaces to n characters
+//	upper s, lower s, trim s
+//	join sep list            joins a list, like the ExtraInformation lines
+//	concept code             description of a common concept code
+//	concepts m               complementary concepts of a movement
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"date": func(t time.Time, layout ...string) string {
+			l := dateLayouts[string(ENGLISH_DATE)]
+			if len(layout) > 0 {
+				l = layout[0]
+				if named, ok := dateLayouts[l]; ok {
+					l = named
+				}
+			}
+			return t.Format(l)
+		},
+		"amount": func(amount float64, options ...string) string {
+			return formatAmount(amount, options...)
+		},
+		"pad": func(size int, s string) string {
+			return field(s, size)
+		},
+		"padLeft": func(size int, s string) string {
+			r := []rune(s)
+			if len(r) >= size {
+				return s
+			}
+			return strings.Repeat(" ", size-len(r)) + s
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"trim":  strings.TrimSpace,
+		"join": func(sep string, list []string) string {
+			return strings.Join(list, sep)
+		},
+		"concept": ConceptDescription,
+		"concepts": func(m *Movement) []string {
+			return m.ComplementaryConcepts()
+		},
+	}
+}
+
+// formatAmount formats an amount with two decimals. The es and en options
+// group the thousands the Spanish or the English way, sign prefixes the
+// positive amounts with + and abs drops the sign.
+func formatAmount(amount float64, options ...string) string {
+	decimal, thousands := ".", ""
+	showSign := false
+
+	for _, option := range options {
+		switch option {
+		case "es":
+			decimal, thousands = ",", "."
+		case "en":
+			decimal, thousands = ".", ","
+		case "sign":
+			showSign = true
+		case "abs":
+			if amount < 0 {
+				amount = -amount
+			}
+		}
+	}
+
+	s := strconv.FormatFloat(amount, 'f', 2, 64)
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	} else if showSign && amount != 0 {
+		sign = "+"
+	}
+
+	integer, decimals := s[:len(s)-3], s[len(s)-2:]
+	if thousands != "" {
+		for i := len(integer) - 3; i > 0; i -= 3 {
+			integer = integer[:i] + thousands + integer[i:]
+		}
+	}
+
+	return sign + integer + decimal + decimals
+}
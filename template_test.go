@@ -0,0 +1,53 @@
+package n43
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+)
+
+func Test_templateFuncs(t *testing.T) {
+	out := parseSample(t)
+
+	for tpl, expected := range map[string]string{
+		`{{ date .Header.StartDate }}`:                                           "2020-02-03",
+		`{{ date .Header.StartDate "DMY" }}`:                                     "03/02/2020",
+		`{{ amount .Header.InitialBalance "es" }}`:                               "2.463,43",
+		`{{ amount .Header.InitialBalance "en" "sign" }}`:                        "+2,463.43",
+		`{{ with index .Movements 0 }}{{ amount .Amount "abs" }}{{ end }}`:       "23.99",
+		`[{{ pad 6 .Header.BankCode }}][{{ padLeft 6 .Header.BankCode }}]`:       "[1111  ][  1111]",
+		`{{ lower (trim .Header.AccountName) | upper }}`:                         "ACCOUNT NAME ************",
+		`{{ with index .Movements 4 }}{{ join "|" .ExtraInformation }}{{ end }}`: "CREDIT CARD 1234567890123456 1234 .CAR GARAGE REPAIR.|CREDIT CARD 1234567890123456 1234 .CAR GARAGE REPAIR EXTRA.",
+		`{{ with index .Movements 0 }}{{ concept .CommonConcept }}{{ end }}`:     ConceptDescription(CONCEPT_CARDS),
+		`{{ with index .Movements 3 }}{{ index (concepts .) 1 }}{{ end }}`:       "ERMARKET WHATEVER NAME INC.",
+	} {
+		var buf bytes.Buffer
+		tmpl := template.Must(template.New("test").Funcs(TemplateFuncs()).Parse(tpl))
+		if err := tmpl.Execute(&buf, out.Accounts[0]); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != expected {
+			t.Errorf("Expected %q for %s, but %q found", expected, tpl, buf.String())
+		}
+	}
+}
+
+func Test_formatAmount(t *testing.T) {
+	for _, c := range []struct {
+		amount   float64
+		options  []string
+		expected string
+	}{
+		{0, nil, "0.00"},
+		{-23.99, []string{"es"}, "-23,99"},
+		{2439.44, []string{"es"}, "2.439,44"},
+		{-1234567.8, []string{"en"}, "-1,234,567.80"},
+		{-1234567.8, []string{"es", "abs"}, "1.234.567,80"},
+		{100, []string{"sign"}, "+100.00"},
+		{0, []string{"sign"}, "0.00"},
+	} {
+		if s := formatAmount(c.amount, c.options...); s != c.expected {
+			t.Errorf("Expected %s, but %s found", c.expected, s)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package n43
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ValidationError is an inconsistency found by Validate. Account is the
+// index of the account it belongs to, or -1 for the whole document.
+type ValidationError struct {
+	Account int
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Account < 0 {
+		return e.Message
+	}
+	return "account " + strconv.Itoa(e.Account+1) + ": " + e.Message
+}
+
+// Validate checks that the footer of every account matches its header and
+// the totals of its movements, and that the end of file record counts every
+// record. Documents parsed with filters report the filtered movements as
+// missing from the totals.
+func (n *Norma43) Validate() []*ValidationError {
+	errs := []*ValidationError{}
+
+	records := 0
+	for i, account := range n.Accounts {
+		errs = append(errs, account.validate(i)...)
+		records += account.Records()
+	}
+
+	if n.ReportedEntries != 0 && n.ReportedEntries != records {
+		errs = append(errs, &ValidationError{
+			Account: -1,
+			Message: fmt.Sprintf("end of file record reports %d records but %d found", n.ReportedEntries, records),
+		})
+	}
+
+	return errs
+}
+
+func (a *Account) validate(idx int) []*ValidationError {
+	errs := []*ValidationError{}
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{Account: idx, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if a.Header == nil {
+		add("missing header")
+	}
+	if a.Footer == nil {
+		add("missing footer")
+	}
+	if a.Header == nil || a.Footer == nil {
+		return errs
+	}
+
+	h, f := a.Header, a.Footer
+	computed := a.ComputeFooter()
+
+	if f.BankCode != h.BankCode || f.BranchCode != h.BranchCode || f.AccountNumber != h.AccountNumber {
+		add("footer account %s %s %s differs from header account %s %s %s", f.BankCode, f.BranchCode, f.AccountNumber, h.BankCode, h.BranchCode, h.AccountNumber)
+	}
+	if f.Currency != h.Currency {
+		add("footer currency %s differs from header currency %s", f.Currency, h.Currency)
+	}
+	if f.DebitEntries != computed.DebitEntries {
+		add("footer reports %d debit entries but %d found", f.DebitEntries, computed.DebitEntries)
+	}
+	if roundAmount(f.DebitAmount) != computed.DebitAmount {
+		add("footer reports %.2f in debits but movements sum %.2f", f.DebitAmount, computed.DebitAmount)
+	}
+	if f.CreditEntries != computed.CreditEntries {
+		add("footer reports %d credit entries but %d found", f.CreditEntries, computed.CreditEntries)
+	}
+	if roundAmount(f.CreditAmount) != computed.CreditAmount {
+		add("footer reports %.2f in credits but movements sum %.2f", f.CreditAmount, computed.CreditAmount)
+	}
+	if roundAmount(f.FinalBalance) != computed.FinalBalance {
+		add("footer final balance %.2f differs from the computed balance %.2f", f.FinalBalance, computed.FinalBalance)
+	}
+	if h.EndDate.Before(h.StartDate) {
+		add("end date %s is before start date %s", h.EndDate.Format("2006-01-02"), h.StartDate.Format("2006-01-02"))
+	}
+
+	return errs
+}
@@ -0,0 +1,46 @@
+package n43
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_validate(t *testing.T) {
+	out := parseSample(t)
+
+	errs := out.Validate()
+	expected := []string{
+		"account 1: footer reports 15 debit entries but 4 found",
+		"account 1: footer reports 661.84 in debits but movements sum 165.57",
+		"account 1: footer reports 500.00 in credits but movements sum 138.57",
+		"account 1: footer final balance 2301.59 differs from the computed balance 2436.43",
+		"end of file record reports 34 records but 13 found",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d validation errors, but %v found", len(expected), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != expected[i] {
+			t.Errorf("Expected %q, but %q found", expected[i], err.Error())
+		}
+	}
+
+	out.Accounts[0].Footer = out.Accounts[0].ComputeFooter()
+	out.ReportedEntries = 13
+	if errs := out.Validate(); len(errs) != 0 {
+		t.Errorf("Expected no validation errors, but %v found", errs)
+	}
+
+	out.ReportedEntries = 34
+	if errs := out.Validate(); len(errs) != 1 || errs[0].Error() != "end of file record reports 34 records but 13 found" {
+		t.Errorf("Expected a record count error, but %v found", errs)
+	}
+
+	out.ReportedEntries = 13
+	out.Accounts[0].Footer.Currency = "840"
+	out.Accounts[0].Footer.FinalBalance = 0
+	errs = out.Validate()
+	if len(errs) != 2 || !strings.Contains(errs[0].Message, "currency") || !strings.Contains(errs[1].Message, "final balance") {
+		t.Errorf("Expected currency and final balance errors, but %v found", errs)
+	}
+}
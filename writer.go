@@ -0,0 +1,192 @@
+package n43
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// Writer writes a Norma43 document as an AEB Norma43 file made of 80
+// character records.
+type Writer struct {
+	w           *bufio.Writer
+	writeOption *WriterOptions
+	records     int
+}
+
+type WriterOptions struct {
+	// CRLF ends the records with CR LF instead of LF.
+	CRLF bool
+	// SkipEndOfFile leaves out the end of file record.
+	SkipEndOfFile bool
+}
+
+func NewWriter(w io.Writer, writerOptions *WriterOptions) *Writer {
+	wo := new(WriterOptions)
+
+	if writerOptions != nil {
+		wo.CRLF = writerOptions.CRLF
+		wo.SkipEndOfFile = writerOptions.SkipEndOfFile
+	}
+
+	return &Writer{
+		w:           bufio.NewWriter(w),
+		writeOption: wo,
+	}
+}
+
+// Write writes every account of the document followed by the end of file
+// record, unless SkipEndOfFile is set. Accounts without footer get one
+// computed from their movements.
+func (w *Writer) Write(n *Norma43) error {
+	w.records = 0
+
+	for _, account := range n.Accounts {
+		h := account.Header
+		if h == nil {
+			h = new(Header)
+		}
+		w.writeHeader(h)
+
+		for _, m := range account.Movements {
+			w.writeMovement(m)
+		}
+
+		f := account.Footer
+		if f == nil {
+			f = account.ComputeFooter()
+		}
+		w.writeFooter(f)
+	}
+
+	if !w.writeOption.SkipEndOfFile {
+		w.writeRecord(field(fmt.Sprintf("88%s%06d", strings.Repeat("9", 18), w.records), 80))
+	}
+
+	return w.w.Flush()
+}
+
+// ComputeFooter returns a footer with the totals of the account movements
+// and the final balance after the last of them.
+func (a *Account) ComputeFooter() *Footer {
+	f := new(Footer)
+
+	balance := float64(0)
+	if a.Header != nil {
+		f.BankCode = a.Header.BankCode
+		f.BranchCode = a.Header.BranchCode
+		f.AccountNumber = a.Header.AccountNumber
+		f.Currency = a.Header.Currency
+		balance = a.Header.InitialBalance
+	}
+
+	for _, m := range a.Movements {
+		if m.Amount < 0 {
+			f.DebitEntries++
+			f.DebitAmount += -m.Amount
+		} else {
+			f.CreditEntries++
+			f.CreditAmount += m.Amount
+		}
+		balance += m.Amount
+	}
+
+	f.DebitAmount = roundAmount(f.DebitAmount)
+	f.CreditAmount = roundAmount(f.CreditAmount)
+	f.FinalBalance = roundAmount(balance)
+
+	return f
+}
+
+func (w *Writer) writeHeader(h *Header) {
+	w.writeRecord("11" +
+		field(h.BankCode, 4) +
+		field(h.BranchCode, 4) +
+		field(h.AccountNumber, 10) +
+		recordDate(h.StartDate) +
+		recordDate(h.EndDate) +
+		recordSign(h.InitialBalance) +
+		recordAmount(h.InitialBalance) +
+		field(h.Currency, 3) +
+		field(h.InformationModeCode, 1) +
+		field(h.AccountName, 29))
+}
+
+func (w *Writer) writeMovement(m *Movement) {
+	w.writeRecord("22" +
+		field("", 4) +
+		field(m.BranchCode, 4) +
+		recordDate(m.TransactionDate) +
+		recordDate(m.ValueDate) +
+		field(m.CommonConcept, 2) +
+		field(m.OwnConcept, 3) +
+		recordSign(m.Amount) +
+		recordAmount(m.Amount) +
+		field(m.DocumentNumber, 10) +
+		field(m.Description, 28))
+
+	for i, line := range m.ExtraInformation {
+		w.writeRecord(fmt.Sprintf("23%02d", i+1) + field(line, 76))
+	}
+}
+
+func (w *Writer) writeFooter(f *Footer) {
+	w.writeRecord("33" +
+		field(f.BankCode, 4) +
+		field(f.BranchCode, 4) +
+		field(f.AccountNumber, 10) +
+		fmt.Sprintf("%05d", f.DebitEntries) +
+		recordAmount(f.DebitAmount) +
+		fmt.Sprintf("%05d", f.CreditEntries) +
+		recordAmount(f.CreditAmount) +
+		recordSign(f.FinalBalance) +
+		recordAmount(f.FinalBalance) +
+		field(f.Currency, 3) +
+		field("", 4))
+}
+
+func (w *Writer) writeRecord(record string) {
+	if !strings.HasPrefix(record, "88") {
+		w.records++
+	}
+
+	w.w.WriteString(record)
+	if w.writeOption.CRLF {
+		w.w.WriteString("\r\n")
+	} else {
+		w.w.WriteString("\n")
+	}
+}
+
+// field pads s with spaces or truncates it to size characters.
+func field(s string, size int) string {
+	r := []rune(s)
+	if len(r) > size {
+		return string(r[:size])
+	}
+	return s + strings.Repeat(" ", size-len(r))
+}
+
+func recordDate(t time.Time) string {
+	return t.Format("060102")
+}
+
+// recordSign returns the record sign of an amount: 1 for debit and 2 for
+// credit.
+func recordSign(amount float64) string {
+	if amount < 0 {
+		return "1"
+	}
+	return "2"
+}
+
+func recordAmount(amount float64) string {
+	return fmt.Sprintf("%014d", int64(math.Round(math.Abs(amount)*100)))
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,65 @@
+package n43
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_writer(t *testing.T) {
+	in := parseSample(t)
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf, nil).Write(in); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 14 {
+		t.Fatalf("Expected 14 records, but %d found", len(lines))
+	}
+
+	for i, line := range lines {
+		if len(line) != 80 {
+			t.Errorf("Expected record %d to be 80 characters long, but %d found: %q", i, len(line), line)
+		}
+	}
+
+	if lines[13] != "88999999999999999999000013"+strings.Repeat(" ", 54) {
+		t.Errorf("Expected end of file record with 13 records, but %q found", lines[13])
+	}
+
+	out, err := NewParser(lines, &ParserOptions{Trim: true}).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(in.Accounts, out.Accounts) {
+		t.Errorf("Expected the same accounts after writing and parsing them again")
+	}
+}
+
+func Test_writerCRLF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewWriter(&buf, &WriterOptions{CRLF: true}).Write(parseSample(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(buf.String(), "\r\n") != 14 {
+		t.Errorf("Expected 14 CRLF terminated records, but %q found", buf.String())
+	}
+}
+
+func Test_computeFooter(t *testing.T) {
+	account := parseSample(t).Accounts[0]
+
+	f := account.ComputeFooter()
+	if f.DebitEntries != 4 || f.DebitAmount != 165.57 || f.CreditEntries != 1 || f.CreditAmount != 138.57 {
+		t.Errorf("Expected 4 debits of 165.57 and 1 credit of 138.57, but %+v found", f)
+	}
+
+	if f.FinalBalance != 2436.43 {
+		t.Errorf("Expected FinalBalance to be 2436.43, but %.2f found", f.FinalBalance)
+	}
+}
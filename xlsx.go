@@ -0,0 +1,382 @@
+package n43
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XLSXWriter writes a Norma43 document as an Excel workbook with a summary
+// sheet followed by one sheet per account.
+type XLSXWriter struct {
+	w io.Writer
+}
+
+type xlsxCellKind int
+
+const (
+	xlsxString xlsxCellKind = iota
+	xlsxNumber
+	xlsxAmount
+	xlsxDate
+	xlsxTitle
+)
+
+// Cell styles, indexes into the cellXfs of xlsxStyles.
+const (
+	xlsxStyleDefault = iota
+	xlsxStyleDate
+	xlsxStyleAmount
+	xlsxStyleTitle
+)
+
+type xlsxCell struct {
+	kind   xlsxCellKind
+	str    string
+	number float64
+	date   time.Time
+}
+
+type xlsxPart struct {
+	name    string
+	content string
+}
+
+type xlsxSheet struct {
+	name string
+	rows [][]xlsxCell
+}
+
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func NewXLSXWriter(w io.Writer) *XLSXWriter {
+	return &XLSXWriter{w: w}
+}
+
+func (x *XLSXWriter) Write(n *Norma43) error {
+	sheets := []*xlsxSheet{xlsxSummarySheet(n)}
+	names := map[string]int{sheets[0].name: 1}
+
+	for _, account := range n.Accounts {
+		sheet := xlsxAccountSheet(account)
+
+		names[sheet.name]++
+		if count := names[sheet.name]; count > 1 {
+			sheet.name = xlsxSheetName(sheet.name, fmt.Sprintf(" (%d)", count))
+		}
+		sheets = append(sheets, sheet)
+	}
+
+	zw := zip.NewWriter(x.w)
+
+	parts := []xlsxPart{
+		{"[Content_Types].xml", xlsxContentTypes(len(sheets))},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook(sheets)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels(len(sheets))},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for i, sheet := range sheets {
+		parts = append(parts, xlsxPart{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet.xml()})
+	}
+
+	for _, part := range parts {
+		fw, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, part.content); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func xlsxSummarySheet(n *Norma43) *xlsxSheet {
+	sheet := &xlsxSheet{name: "Summary"}
+	sheet.rows = append(sheet.rows, []xlsxCell{
+		xlsxTitleCell("Bank code"),
+		xlsxTitleCell("Branch code"),
+		xlsxTitleCell("Account number"),
+		xlsxTitleCell("Account name"),
+		xlsxTitleCell("Currency"),
+		xlsxTitleCell("Start date"),
+		xlsxTitleCell("End date"),
+		xlsxTitleCell("Initial balance"),
+		xlsxTitleCell("Debit entries"),
+		xlsxTitleCell("Debit amount"),
+		xlsxTitleCell("Credit entries"),
+		xlsxTitleCell("Credit amount"),
+		xlsxTitleCell("Final balance"),
+	})
+
+	for _, account := range n.Accounts {
+		row := make([]xlsxCell, 0, 13)
+
+		h := account.Header
+		if h == nil {
+			h = new(Header)
+		}
+		row = append(row,
+			xlsxStringCell(h.BankCode),
+			xlsxStringCell(h.BranchCode),
+			xlsxStringCell(h.AccountNumber),
+			xlsxStringCell(strings.TrimSpace(h.AccountName)),
+			xlsxStringCell(h.Currency),
+			xlsxDateCell(h.StartDate),
+			xlsxDateCell(h.EndDate),
+			xlsxAmountCell(h.InitialBalance),
+		)
+
+		if f := account.Footer; f != nil {
+			row = append(row,
+				xlsxNumberCell(float64(f.DebitEntries)),
+				xlsxAmountCell(f.DebitAmount),
+				xlsxNumberCell(float64(f.CreditEntries)),
+				xlsxAmountCell(f.CreditAmount),
+				xlsxAmountCell(f.FinalBalance),
+			)
+		}
+		sheet.rows = append(sheet.rows, row)
+	}
+
+	return sheet
+}
+
+func xlsxAccountSheet(account *Account) *xlsxSheet {
+	h := account.Header
+	if h == nil {
+		h = new(Header)
+	}
+
+	sheet := &xlsxSheet{name: xlsxSheetName(strings.TrimSpace(h.BankCode+" "+h.AccountNumber), "")}
+	if sheet.name == "" {
+		sheet.name = "Account"
+	}
+
+	sheet.rows = append(sheet.rows,
+		[]xlsxCell{xlsxTitleCell("Bank code"), xlsxStringCell(h.BankCode)},
+		[]xlsxCell{xlsxTitleCell("Branch code"), xlsxStringCell(h.BranchCode)},
+		[]xlsxCell{xlsxTitleCell("Account number"), xlsxStringCell(h.AccountNumber)},
+		[]xlsxCell{xlsxTitleCell("Account name"), xlsxStringCell(strings.TrimSpace(h.AccountName))},
+		[]xlsxCell{xlsxTitleCell("Currency"), xlsxStringCell(h.Currency)},
+		[]xlsxCell{xlsxTitleCell("Start date"), xlsxDateCell(h.StartDate)},
+		[]xlsxCell{xlsxTitleCell("End date"), xlsxDateCell(h.EndDate)},
+		[]xlsxCell{xlsxTitleCell("Initial balance"), xlsxAmountCell(h.InitialBalance)},
+		nil,
+		[]xlsxCell{
+			xlsxTitleCell("Branch code"),
+			xlsxTitleCell("Transaction date"),
+			xlsxTitleCell("Value date"),
+			xlsxTitleCell("Amount"),
+			xlsxTitleCell("Balance"),
+			xlsxTitleCell("Description"),
+			xlsxTitleCell("Extra information"),
+			xlsxTitleCell("Category"),
+			xlsxTitleCell("Tags"),
+		},
+	)
+
+	for _, m := range account.Movements {
+		extra := make([]string, 0, len(m.ExtraInformation))
+		for _, line := range m.ExtraInformation {
+			extra = append(extra, strings.TrimSpace(line))
+		}
+
+		sheet.rows = append(sheet.rows, []xlsxCell{
+			xlsxStringCell(m.BranchCode),
+			xlsxDateCell(m.TransactionDate),
+			xlsxDateCell(m.ValueDate),
+			xlsxAmountCell(m.Amount),
+			xlsxAmountCell(m.Balance),
+			xlsxStringCell(strings.TrimSpace(m.Description)),
+			xlsxStringCell(strings.Join(extra, " ")),
+			xlsxStringCell(m.Category),
+			xlsxStringCell(strings.Join(m.Tags, " ")),
+		})
+	}
+
+	if f := account.Footer; f != nil {
+		sheet.rows = append(sheet.rows,
+			nil,
+			[]xlsxCell{
+				xlsxTitleCell("Debit entries"),
+				xlsxTitleCell("Debit amount"),
+				xlsxTitleCell("Credit entries"),
+				xlsxTitleCell("Credit amount"),
+				xlsxTitleCell("Final balance"),
+				xlsxTitleCell("Currency"),
+			},
+			[]xlsxCell{
+				xlsxNumberCell(float64(f.DebitEntries)),
+				xlsxAmountCell(f.DebitAmount),
+				xlsxNumberCell(float64(f.CreditEntries)),
+				xlsxAmountCell(f.CreditAmount),
+				xlsxAmountCell(f.FinalBalance),
+				xlsxStringCell(f.Currency),
+			},
+		)
+	}
+
+	return sheet
+}
+
+func xlsxStringCell(s string) xlsxCell {
+	return xlsxCell{kind: xlsxString, str: s}
+}
+
+func xlsxTitleCell(s string) xlsxCell {
+	return xlsxCell{kind: xlsxTitle, str: s}
+}
+
+func xlsxNumberCell(n float64) xlsxCell {
+	return xlsxCell{kind: xlsxNumber, number: n}
+}
+
+func xlsxAmountCell(n float64) xlsxCell {
+	return xlsxCell{kind: xlsxAmount, number: n}
+}
+
+func xlsxDateCell(t time.Time) xlsxCell {
+	return xlsxCell{kind: xlsxDate, date: t}
+}
+
+// xlsxColumn returns the spreadsheet column name (A, B, ..., AA, ...) of the
+// zero based column index.
+func xlsxColumn(idx int) string {
+	name := ""
+	for idx++; idx > 0; idx = (idx - 1) / 26 {
+		name = string(rune('A'+(idx-1)%26)) + name
+	}
+	return name
+}
+
+func xlsxEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// xlsxSheetName removes the characters Excel does not allow in sheet names
+// and truncates the name so that it is 31 characters long at most once suffix
+// is appended.
+func xlsxSheetName(name string, suffix string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	if runes := []rune(name); len(runes)+len(suffix) > 31 {
+		name = string(runes[:31-len(suffix)])
+	}
+	return name + suffix
+}
+
+func (s *xlsxSheet) xml() string {
+	var b strings.Builder
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	for i, row := range s.rows {
+		if len(row) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, cell := range row {
+			ref := xlsxColumn(j) + strconv.Itoa(i+1)
+
+			switch cell.kind {
+			case xlsxString:
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xlsxEscape(cell.str))
+			case xlsxTitle:
+				fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t>%s</t></is></c>`, ref, xlsxStyleTitle, xlsxEscape(cell.str))
+			case xlsxNumber:
+				fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(cell.number, 'f', -1, 64))
+			case xlsxAmount:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleAmount, strconv.FormatFloat(cell.number, 'f', 2, 64))
+			case xlsxDate:
+				if cell.date.IsZero() {
+					continue
+				}
+				days := cell.date.Sub(xlsxEpoch).Hours() / 24
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleDate, strconv.FormatFloat(days, 'f', -1, 64))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+func xlsxContentTypes(sheets int) string {
+	var b strings.Builder
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+
+	return b.String()
+}
+
+func xlsxWorkbook(sheets []*xlsxSheet) string {
+	var b strings.Builder
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, sheet := range sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xlsxEscape(sheet.name), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+
+	return b.String()
+}
+
+func xlsxWorkbookRels(sheets int) string {
+	var b strings.Builder
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheets+1)
+	b.WriteString(`</Relationships>`)
+
+	return b.String()
+}
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -0,0 +1,99 @@
+package n43
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func Test_xlsx(t *testing.T) {
+	out := parseSample(t)
+	out.Accounts[0].Movements[0].Category = "Shopping"
+	out.Accounts[0].Movements[0].Tags = []string{"card", "home"}
+
+	var buf bytes.Buffer
+	if err := NewXLSXWriter(&buf).Write(out); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(data)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "xl/workbook.xml", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("Expected part %s in the workbook", name)
+		}
+	}
+
+	if !strings.Contains(parts["xl/workbook.xml"], `<sheet name="1111 3333444412" sheetId="2" r:id="rId2"/>`) {
+		t.Errorf("Expected a sheet for account 1111 3333444412, but workbook is %s", parts["xl/workbook.xml"])
+	}
+
+	sheet := parts["xl/worksheets/sheet2.xml"]
+
+	// 2020-02-03 is the serial date 43864 and the first movement is in row 11.
+	if !strings.Contains(sheet, `<c r="B11" s="1"><v>43864</v></c>`) {
+		t.Errorf("Expected typed transaction date in B11, but sheet is %s", sheet)
+	}
+
+	if !strings.Contains(sheet, `<c r="D11" s="2"><v>-23.99</v></c>`) {
+		t.Errorf("Expected typed amount in D11, but sheet is %s", sheet)
+	}
+
+	if !strings.Contains(sheet, `<c r="I10" s="3" t="inlineStr"><is><t>Tags</t></is></c>`) || !strings.Contains(sheet, `<c r="I11" t="inlineStr"><is><t xml:space="preserve">card home</t></is></c>`) {
+		t.Errorf("Expected the tags in column I, but sheet is %s", sheet)
+	}
+
+	if !strings.Contains(sheet, `<c r="E18" s="2"><v>2301.59</v></c>`) {
+		t.Errorf("Expected footer final balance in E18, but sheet is %s", sheet)
+	}
+}
+
+func Test_xlsxColumn(t *testing.T) {
+	for idx, expected := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if col := xlsxColumn(idx); col != expected {
+			t.Errorf("Expected column %d to be %s, but %s found", idx, expected, col)
+		}
+	}
+}
+
+func Test_xlsxSheetName(t *testing.T) {
+	long := strings.Repeat("A", 40)
+
+	tests := []struct {
+		name     string
+		suffix   string
+		expected string
+	}{
+		{"1111 3333444412", "", "1111 3333444412"},
+		{"1111 3333444412", " (2)", "1111 3333444412 (2)"},
+		{"a/b:c", "", "a_b_c"},
+		{long, "", long[:31]},
+		{long, " (2)", long[:27] + " (2)"},
+		{long, " (12)", long[:26] + " (12)"},
+	}
+
+	for _, test := range tests {
+		if name := xlsxSheetName(test.name, test.suffix); name != test.expected {
+			t.Errorf("Expected sheet name %s, but %s found", test.expected, name)
+		}
+	}
+}